@@ -0,0 +1,102 @@
+package http
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/lameaux/golang-product-reviews/productmanager"
+)
+
+// Tokens authenticate bearer tokens as principals.
+type Tokens struct {
+	digests    [][sha256.Size]byte
+	principals []string
+}
+
+// ParseTokens reads a comma-separated list of principal:token pairs.
+func ParseTokens(val string) (*Tokens, error) {
+	t := &Tokens{}
+	for pair := range strings.SplitSeq(val, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		principal, token, ok := strings.Cut(pair, ":")
+		if !ok || principal == "" || len(token) < 16 {
+			return nil, fmt.Errorf("invalid token of %q, expected principal:token with a token of 16 or more characters", principal)
+		}
+
+		t.digests = append(t.digests, sha256.Sum256([]byte(token)))
+		t.principals = append(t.principals, principal)
+	}
+
+	return t, nil
+}
+
+// Authenticate returns the principal of the token, compared in constant time.
+func (t *Tokens) Authenticate(token string) (string, bool) {
+	digest := sha256.Sum256([]byte(token))
+
+	principal, found := "", false
+	for i := range t.digests {
+		if subtle.ConstantTimeCompare(digest[:], t.digests[i][:]) == 1 {
+			principal, found = t.principals[i], true
+		}
+	}
+	return principal, found
+}
+
+// SetAuth authenticates bearer tokens and guards /admin with the authorizer if it is set.
+// The manager authorizes its own calls, the guard covers admin routes that don't go through it.
+func (s *Server) SetAuth(tokens *Tokens, authorizer productmanager.Authorizer) {
+	s.tokens = tokens
+	s.authorizer = authorizer
+}
+
+// authMiddleware attaches the principal of the bearer token, requests without
+// a token stay anonymous and requests with an unknown one are rejected.
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		principal, authenticated := "", false
+		if ok {
+			principal, authenticated = s.tokens.Authenticate(token)
+		}
+		if !authenticated {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+			http.Error(w, "authMiddleware - invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(productmanager.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// adminMiddleware lets only admins through.
+func (s *Server) adminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, err := mux.CurrentRoute(r).GetPathTemplate()
+		if err != nil {
+			path = r.URL.Path
+		}
+
+		call := &productmanager.Call{Method: r.Method + " " + path, Kind: productmanager.KindAdmin}
+		if err := s.authorizer.Authorize(r.Context(), call); err != nil {
+			sendError(w, "adminMiddleware - Authorize", err)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/lameaux/golang-product-reviews/dto"
+)
+
+func (s *Server) setupAdminRouter(r *mux.Router) {
+	r.HandleFunc("/reviewers/export", s.handleExportReviewer()).Methods("POST")
+	r.HandleFunc("/reviewers/erase", s.handleEraseReviewer()).Methods("POST")
+}
+
+func (s *Server) handleExportReviewer() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		var reviewer dto.Reviewer
+		decoder := json.NewDecoder(r.Body)
+		if err := decoder.Decode(&reviewer); err != nil {
+			http.Error(w, "handleExportReviewer - decode: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := validate.Struct(&reviewer); err != nil {
+			http.Error(w, "handleExportReviewer - validate: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		export, err := s.manager.ExportReviewerData(r.Context(), &reviewer)
+		if err != nil {
+			sendError(w, "handleExportReviewer - ExportReviewerData", err)
+			return
+		}
+
+		s.sendAsJSON(w, export)
+	}
+}
+
+func (s *Server) handleEraseReviewer() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		var req dto.ErasureRequest
+		decoder := json.NewDecoder(r.Body)
+		if err := decoder.Decode(&req); err != nil {
+			http.Error(w, "handleEraseReviewer - decode: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := validate.Struct(&req); err != nil {
+			http.Error(w, "handleEraseReviewer - validate: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		result, err := s.manager.EraseReviewerData(r.Context(), &req)
+		if err != nil {
+			sendError(w, "handleEraseReviewer - EraseReviewerData", err)
+			return
+		}
+
+		s.sendAsJSON(w, result)
+	}
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleExportReviewer(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "missing body",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "handleExportReviewer - decode",
+		},
+		{
+			name:       "empty body",
+			body:       "{}",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "handleExportReviewer - validate",
+		},
+		{
+			name:       "valid",
+			body:       `{"first_name":"Sergej","last_name":"Sizov"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"first_name":"Sergej","last_name":"Sizov","reviews":[{"product_id":1,"id":1,"first_name":"Sergej","last_name":"Sizov","review":"Perfect","rating":5}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/admin/reviewers/export", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+
+			testRouter().ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			require.Contains(t, strings.TrimSpace(rec.Body.String()), tt.wantBody)
+		})
+	}
+}
+
+func TestHandleEraseReviewer(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "missing body",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "handleEraseReviewer - decode",
+		},
+		{
+			name:       "invalid mode",
+			body:       `{"first_name":"Sergej","last_name":"Sizov","mode":"forget"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "handleEraseReviewer - validate",
+		},
+		{
+			name:       "valid",
+			body:       `{"first_name":"Sergej","last_name":"Sizov","mode":"anonymize"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"mode":"anonymize","reviews":1,"hash":""}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/admin/reviewers/erase", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+
+			testRouter().ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			require.Contains(t, strings.TrimSpace(rec.Body.String()), tt.wantBody)
+		})
+	}
+}
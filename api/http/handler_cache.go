@@ -0,0 +1,121 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/lameaux/golang-product-reviews/cache"
+	"github.com/lameaux/golang-product-reviews/dto"
+)
+
+func (s *Server) setupCacheRouter(r *mux.Router) {
+	r.HandleFunc("", s.handleFlushCache()).Methods("DELETE")
+	r.HandleFunc("/warm", s.handleWarmCache()).Methods("POST")
+	r.HandleFunc("/products/{product_id}", s.handleGetProductCache()).Methods("GET")
+	r.HandleFunc("/products/{product_id}", s.handleFlushProductCache()).Methods("DELETE")
+}
+
+func (s *Server) handleGetProductCache() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := getProductID(r)
+		if err != nil {
+			http.Error(w, "handleGetProductCache - getProductID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		gen, entries, err := s.cacheAdmin.ProductEntries(r.Context(), productID)
+		if err != nil {
+			sendError(w, "handleGetProductCache - ProductEntries", err)
+			return
+		}
+
+		result := dto.CacheEntries{
+			ProductID:  productID,
+			Generation: int64(gen),
+			Entries:    make([]dto.CacheEntry, 0, len(entries)),
+		}
+		for _, entry := range entries {
+			result.Entries = append(result.Entries, convertCacheEntry(entry))
+		}
+
+		s.sendAsJSON(w, result)
+	}
+}
+
+func (s *Server) handleFlushProductCache() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := getProductID(r)
+		if err != nil {
+			http.Error(w, "handleFlushProductCache - getProductID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		deleted, err := s.cacheAdmin.FlushProduct(r.Context(), productID)
+		if err != nil {
+			sendError(w, "handleFlushProductCache - FlushProduct", err)
+			return
+		}
+
+		s.sendAsJSON(w, dto.CacheFlush{Deleted: deleted})
+	}
+}
+
+func (s *Server) handleFlushCache() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		deleted, err := s.cacheAdmin.FlushAll(r.Context())
+		if err != nil {
+			sendError(w, "handleFlushCache - FlushAll", err)
+			return
+		}
+
+		s.sendAsJSON(w, dto.CacheFlush{Deleted: deleted})
+	}
+}
+
+func (s *Server) handleWarmCache() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		// the body is optional, defaults are used without it
+		var req dto.CacheWarmRequest
+		decoder := json.NewDecoder(r.Body)
+		if err := decoder.Decode(&req); err != nil && err != io.EOF {
+			http.Error(w, "handleWarmCache - decode: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := validate.Struct(&req); err != nil {
+			http.Error(w, "handleWarmCache - validate: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		result, err := s.manager.WarmCache(r.Context(), &req)
+		if err != nil {
+			sendError(w, "handleWarmCache - WarmCache", err)
+			return
+		}
+
+		s.sendAsJSON(w, result)
+	}
+}
+
+func convertCacheEntry(entry cache.Entry) dto.CacheEntry {
+	result := dto.CacheEntry{
+		Key:   entry.Key,
+		Kind:  entry.Kind,
+		TTLMs: -1,
+	}
+
+	if entry.TTL >= 0 {
+		result.TTLMs = entry.TTL.Milliseconds()
+	}
+
+	if entry.Kind == cache.KindValue {
+		softTTL := entry.SoftTTL.Milliseconds()
+		result.SoftTTLMs = &softTTL
+	}
+
+	return result
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleGetProductCache(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "invalid id",
+			path:       "/admin/cache/products/abc",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "handleGetProductCache - getProductID",
+		},
+		{
+			name:       "valid",
+			path:       "/admin/cache/products/1",
+			wantStatus: http.StatusOK,
+			wantBody: `{"product_id":1,"generation":3,"entries":[` +
+				`{"key":"products:1:generation","kind":"generation","ttl_ms":-1},` +
+				`{"key":"products:1:3:product","kind":"value","ttl_ms":3600000,"soft_ttl_ms":300000},` +
+				`{"key":"products:1:3:review:2","kind":"tombstone","ttl_ms":30000}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			testRouter().ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			require.Contains(t, strings.TrimSpace(rec.Body.String()), tt.wantBody)
+		})
+	}
+}
+
+func TestHandleFlushCache(t *testing.T) {
+	for _, path := range []string{"/admin/cache", "/admin/cache/products/1"} {
+		t.Run(path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, path, nil)
+			rec := httptest.NewRecorder()
+
+			testRouter().ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.Equal(t, `{"deleted":3}`, strings.TrimSpace(rec.Body.String()))
+		})
+	}
+}
+
+func TestHandleWarmCache(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "defaults",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"by":"","products":1,"failed":0}`,
+		},
+		{
+			name:       "by traffic",
+			body:       `{"by":"traffic","limit":10}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"by":"traffic","products":1,"failed":0}`,
+		},
+		{
+			name:       "invalid order",
+			body:       `{"by":"price"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "handleWarmCache - validate",
+		},
+		{
+			name:       "invalid body",
+			body:       `[`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "handleWarmCache - decode",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/admin/cache/warm", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+
+			testRouter().ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			require.Contains(t, strings.TrimSpace(rec.Body.String()), tt.wantBody)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lameaux/golang-product-reviews/dto"
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/lameaux/golang-product-reviews/feed"
+	"github.com/lameaux/golang-product-reviews/model"
+)
+
+const heartbeatInterval = 15 * time.Second
+
+func (s *Server) setupEventsRouter(r *mux.Router, products *mux.Router) {
+	r.HandleFunc("/events", s.handleEvents()).Methods("GET")
+	products.HandleFunc("/{product_id}/events", s.handleProductEvents()).Methods("GET")
+}
+
+func (s *Server) handleEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.streamEvents(w, r, 0)
+	}
+}
+
+func (s *Server) handleProductEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := getProductID(r)
+		if err != nil {
+			http.Error(w, "handleProductEvents - getProductID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		s.streamEvents(w, r, productID)
+	}
+}
+
+// streamEvents sends events as Server-Sent Events until the client disconnects.
+// Clients resume with the Last-Event-ID header from the broker buffer.
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request, productID model.ID) {
+	if s.feed == nil {
+		http.Error(w, "streamEvents - live feed is disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var lastEventID uint64
+	if val := r.Header.Get("Last-Event-ID"); val != "" {
+		var err error
+		if lastEventID, err = strconv.ParseUint(val, 10, 64); err != nil {
+			http.Error(w, "streamEvents - invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	rc := http.NewResponseController(w)
+	// the stream outlives the server write timeout
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	sub := s.feed.Subscribe(productID, lastEventID)
+	defer s.feed.Unsubscribe(sub)
+
+	for _, entry := range sub.Backlog {
+		if err := s.writeEvent(w, r, productID, entry); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case entry, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if err := s.writeEvent(w, r, productID, entry); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) writeEvent(w http.ResponseWriter, r *http.Request, productID model.ID, entry feed.Entry) error {
+	data, err := events.Encode(entry.Event)
+	if err != nil {
+		s.logger.Error().Err(err).Uint64("id", entry.ID).Msg("encode event failed")
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", entry.ID, entry.Event.Action, data); err != nil {
+		return err
+	}
+
+	// product pages also get the updated rating after every review change
+	if productID == 0 || entry.Event.ReviewID == 0 {
+		return nil
+	}
+
+	product, err := s.manager.GetProduct(r.Context(), productID)
+	if err != nil || product == nil {
+		return nil
+	}
+
+	rating, err := json.Marshal(dto.ProductRating{ProductID: productID, Rating: product.Rating})
+	if err != nil {
+		return nil
+	}
+
+	_, err = fmt.Fprintf(w, "event: rating\ndata: %s\n\n", rating)
+	return err
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/lameaux/golang-product-reviews/feed"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleEvents(t *testing.T) {
+	broker := feed.NewBroker(10)
+	srv := httptest.NewServer(New(0, &log.Logger, stubProductManager(), stubWebhookManager(), stubCacheAdmin(), broker).CreateRouter())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/products/1/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// headers are flushed after subscribing, so the events below are live
+	broker.Publish(feed.Entry{ID: 1, Event: testEvent(2, 2)})
+	broker.Publish(feed.Entry{ID: 2, Event: testEvent(1, 1)})
+
+	want := "id: 2\nevent: create\n" +
+		`data: {"schema_version":2,"id":"e","time":"2025-01-02T03:04:05Z","action":"create","product_id":1,"review_id":1}` + "\n\n" +
+		"event: rating\n" +
+		`data: {"product_id":1,"rating":1}` + "\n\n"
+
+	got := make([]byte, len(want))
+	_, err = io.ReadFull(resp.Body, got)
+	require.NoError(t, err)
+	assert.Equal(t, want, string(got))
+}
+
+func TestHandleEvents_Resume(t *testing.T) {
+	broker := feed.NewBroker(10)
+	router := New(0, &log.Logger, stubProductManager(), stubWebhookManager(), stubCacheAdmin(), broker).CreateRouter()
+
+	for id := 1; id <= 3; id++ {
+		broker.Publish(feed.Entry{ID: uint64(id), Event: testEvent(id%2+1, id)})
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+	req.Header.Set("Last-Event-ID", "1")
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "id: 2\nevent: create\n"+
+		`data: {"schema_version":2,"id":"e","time":"2025-01-02T03:04:05Z","action":"create","product_id":1,"review_id":2}`+"\n\n"+
+		"id: 3\nevent: create\n"+
+		`data: {"schema_version":2,"id":"e","time":"2025-01-02T03:04:05Z","action":"create","product_id":2,"review_id":3}`+"\n\n",
+		rec.Body.String())
+}
+
+func TestHandleEvents_InvalidLastEventID(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Last-Event-ID", "abc")
+	rec := httptest.NewRecorder()
+
+	testRouter().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func testEvent(productID int, reviewID int) *events.Event {
+	event := events.New(context.Background(), events.ActionCreate, productID, reviewID)
+	event.ID = "e"
+	event.Time = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	return event
+}
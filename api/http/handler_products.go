@@ -14,6 +14,7 @@ func (s *Server) setupProductsRouter(r *mux.Router) {
 	r.HandleFunc("/{product_id}", s.handleGetProduct()).Methods("GET")
 	r.HandleFunc("", s.handlePostProduct()).Methods("POST")
 	r.HandleFunc("/{product_id}", s.handlePutProduct()).Methods("PUT")
+	r.HandleFunc("/{product_id}", s.handlePatchProduct()).Methods("PATCH")
 	r.HandleFunc("/{product_id}", s.handleDeleteProduct()).Methods("DELETE")
 }
 
@@ -33,7 +34,7 @@ func (s *Server) handleListProducts() http.HandlerFunc {
 
 		products, err := s.manager.ListProducts(r.Context(), offset, limit)
 		if err != nil {
-			http.Error(w, "handleListProducts - ListProducts: "+err.Error(), http.StatusInternalServerError)
+			sendError(w, "handleListProducts - ListProducts", err)
 			return
 		}
 
@@ -51,7 +52,7 @@ func (s *Server) handleGetProduct() http.HandlerFunc {
 
 		product, err := s.manager.GetProduct(r.Context(), productID)
 		if err != nil {
-			http.Error(w, "handleGetProduct - GetProduct: "+err.Error(), http.StatusInternalServerError)
+			sendError(w, "handleGetProduct - GetProduct", err)
 			return
 		}
 
@@ -82,7 +83,7 @@ func (s *Server) handlePostProduct() http.HandlerFunc {
 
 		productID, err := s.manager.CreateProduct(r.Context(), &product)
 		if err != nil {
-			http.Error(w, "handlePostProduct - CreateProduct: "+err.Error(), http.StatusInternalServerError)
+			sendError(w, "handlePostProduct - CreateProduct", err)
 			return
 		}
 
@@ -115,7 +116,7 @@ func (s *Server) handlePutProduct() http.HandlerFunc {
 		}
 
 		if err := s.manager.UpdateProduct(r.Context(), productID, &product); err != nil {
-			http.Error(w, "handlePutProduct - manager: "+err.Error(), http.StatusInternalServerError)
+			sendError(w, "handlePutProduct - manager", err)
 			return
 		}
 
@@ -123,6 +124,57 @@ func (s *Server) handlePutProduct() http.HandlerFunc {
 	}
 }
 
+func (s *Server) handlePatchProduct() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		productID, err := getProductID(r)
+		if err != nil {
+			http.Error(w, "handlePatchProduct - getProductID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		patch, err := readMergePatch(r)
+		if err != nil {
+			sendPatchError(w, "handlePatchProduct - readMergePatch", err)
+			return
+		}
+
+		var invalid error
+		product, err := s.manager.PatchProduct(r.Context(), productID, func(p *dto.Product) error {
+			if err := applyMergePatch(p, patch); err != nil {
+				invalid = fmt.Errorf("applyMergePatch: %w", err)
+				return invalid
+			}
+
+			p.ID = productID
+
+			if err := validate.Struct(p); err != nil {
+				invalid = fmt.Errorf("validate: %w", err)
+				return invalid
+			}
+
+			return nil
+		})
+		if invalid != nil {
+			http.Error(w, "handlePatchProduct - "+invalid.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err != nil {
+			sendError(w, "handlePatchProduct - manager", err)
+			return
+		}
+
+		if product == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		s.sendAsJSON(w, product)
+	}
+}
+
 func (s *Server) handleDeleteProduct() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		productID, err := getProductID(r)
@@ -132,7 +184,7 @@ func (s *Server) handleDeleteProduct() http.HandlerFunc {
 		}
 
 		if err := s.manager.DeleteProduct(r.Context(), productID); err != nil {
-			http.Error(w, "handleDeleteProduct - manager: "+err.Error(), http.StatusInternalServerError)
+			sendError(w, "handleDeleteProduct - manager", err)
 			return
 		}
 
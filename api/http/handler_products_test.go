@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/lameaux/golang-product-reviews/productmanager"
 	"github.com/stretchr/testify/require"
 )
 
@@ -51,16 +52,24 @@ func TestHandleListProducts(t *testing.T) {
 
 func TestHandleGetProduct(t *testing.T) {
 	tests := []struct {
-		name       string
-		id         int
-		wantStatus int
-		wantBody   string
+		name           string
+		id             int
+		wantStatus     int
+		wantBody       string
+		wantRetryAfter string
 	}{
 		{
 			name:       "invalid id",
 			id:         404,
 			wantStatus: http.StatusNotFound,
 		},
+		{
+			name:           "locked",
+			id:             productmanager.StubLockedID,
+			wantStatus:     http.StatusServiceUnavailable,
+			wantBody:       "handleGetProduct - GetProduct: locked",
+			wantRetryAfter: "1",
+		},
 		{
 			name:       "valid id",
 			id:         1,
@@ -77,6 +86,7 @@ func TestHandleGetProduct(t *testing.T) {
 
 			require.Equal(t, tt.wantStatus, rec.Code)
 			require.Equal(t, tt.wantBody, strings.TrimSpace(rec.Body.String()))
+			require.Equal(t, tt.wantRetryAfter, rec.Header().Get("Retry-After"))
 		})
 	}
 }
@@ -162,6 +172,69 @@ func TestHandlePutProduct(t *testing.T) {
 	}
 }
 
+func TestHandlePatchProduct(t *testing.T) {
+	tests := []struct {
+		name        string
+		id          int
+		contentType string
+		body        string
+		wantStatus  int
+		wantBody    string
+	}{
+		{
+			name:        "wrong content type",
+			id:          1,
+			contentType: "application/json",
+			body:        `{"price":200}`,
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantBody:    "handlePatchProduct - readMergePatch: unsupported media type",
+		},
+		{
+			name:        "invalid json",
+			id:          1,
+			contentType: mergePatchContentType,
+			body:        `{"price":`,
+			wantStatus:  http.StatusBadRequest,
+			wantBody:    "handlePatchProduct - readMergePatch: decode",
+		},
+		{
+			name:        "required field removed",
+			id:          1,
+			contentType: mergePatchContentType,
+			body:        `{"name":null}`,
+			wantStatus:  http.StatusBadRequest,
+			wantBody:    "handlePatchProduct - validate",
+		},
+		{
+			name:        "not found",
+			id:          404,
+			contentType: mergePatchContentType,
+			body:        `{"price":200}`,
+			wantStatus:  http.StatusNotFound,
+		},
+		{
+			name:        "valid",
+			id:          1,
+			contentType: mergePatchContentType,
+			body:        `{"price":200,"id":7}`,
+			wantStatus:  http.StatusOK,
+			wantBody:    `{"id":1,"name":"P1","description":"P1 desc","price":200}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/products/%d", tt.id), strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			testRouter().ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			require.Contains(t, strings.TrimSpace(rec.Body.String()), tt.wantBody)
+		})
+	}
+}
+
 func TestHandleDeleteProduct(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/lameaux/golang-product-reviews/dto"
+)
+
+// maxReviewBatch is the largest number of reviews in a batch.
+const maxReviewBatch = 100
+
+func (s *Server) setupReviewBatchRouter(r *mux.Router, products *mux.Router) {
+	r.HandleFunc("/reviews:batch", s.handlePostReviewBatch()).Methods("POST")
+	products.HandleFunc("/{product_id}/reviews:batch", s.handlePostProductReviewBatch()).Methods("POST")
+}
+
+func (s *Server) handlePostReviewBatch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		batch, err := decodeReviewBatch(r)
+		if err != nil {
+			http.Error(w, "handlePostReviewBatch - "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		s.createReviewBatch(w, r, "handlePostReviewBatch", batch)
+	}
+}
+
+func (s *Server) handlePostProductReviewBatch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		productID, err := getProductID(r)
+		if err != nil {
+			http.Error(w, "handlePostProductReviewBatch - getProductID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		batch, err := decodeReviewBatch(r)
+		if err != nil {
+			http.Error(w, "handlePostProductReviewBatch - "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		for _, review := range batch.Reviews {
+			if review != nil {
+				review.ProductID = productID
+			}
+		}
+
+		s.createReviewBatch(w, r, "handlePostProductReviewBatch", batch)
+	}
+}
+
+func decodeReviewBatch(r *http.Request) (*dto.ReviewBatch, error) {
+	var batch dto.ReviewBatch
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	if len(batch.Reviews) == 0 || len(batch.Reviews) > maxReviewBatch {
+		return nil, fmt.Errorf("validate: batch must have 1 to %d reviews", maxReviewBatch)
+	}
+
+	return &batch, nil
+}
+
+// createReviewBatch validates every review on its own and creates the valid ones.
+// It responds with 200 if all reviews are created and with 207 otherwise.
+func (s *Server) createReviewBatch(w http.ResponseWriter, r *http.Request, handler string, batch *dto.ReviewBatch) {
+	result := &dto.ReviewBatchResult{Items: make([]*dto.ReviewBatchItem, len(batch.Reviews))}
+
+	var valid []*dto.BatchReview
+	var indexes []int
+	for i, review := range batch.Reviews {
+		if err := validateBatchReview(review); err != nil {
+			item := &dto.ReviewBatchItem{Index: i, Status: dto.BatchItemInvalid, Error: err.Error()}
+			if review != nil {
+				item.ProductID = review.ProductID
+			}
+			result.Items[i] = item
+			continue
+		}
+
+		valid = append(valid, review)
+		indexes = append(indexes, i)
+	}
+
+	if len(valid) > 0 {
+		items, err := s.manager.CreateProductReviews(r.Context(), valid)
+		if err != nil {
+			sendError(w, handler+" - CreateProductReviews", err)
+			return
+		}
+
+		for i, item := range items {
+			item.Index = indexes[i]
+			result.Items[item.Index] = item
+		}
+	}
+
+	for _, item := range result.Items {
+		if item.Status == dto.BatchItemCreated {
+			result.Created++
+		} else {
+			result.Failed++
+		}
+	}
+
+	status := http.StatusOK
+	if result.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		s.logger.Error().Err(err).Msg("encode response failed")
+	}
+}
+
+func validateBatchReview(review *dto.BatchReview) error {
+	if review == nil {
+		return errors.New("review is missing")
+	}
+
+	if review.ProductID < 1 {
+		return errors.New("product_id is required")
+	}
+
+	return validate.Struct(&review.Review)
+}
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lameaux/golang-product-reviews/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlePostReviewBatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+		wantItems  []string
+	}{
+		{
+			name:       "empty batch",
+			path:       "/reviews:batch",
+			body:       `{"reviews":[]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "too large batch",
+			path:       "/reviews:batch",
+			body:       `{"reviews":[` + strings.Repeat(`{},`, maxReviewBatch) + `{}]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "all created",
+			path: "/reviews:batch",
+			body: `{"reviews":[
+				{"product_id":1,"first_name":"A","last_name":"B","review":"Good","rating":4}
+			]}`,
+			wantStatus: http.StatusOK,
+			wantItems:  []string{dto.BatchItemCreated},
+		},
+		{
+			name: "partial success",
+			path: "/reviews:batch",
+			body: `{"reviews":[
+				{"product_id":1,"first_name":"A","last_name":"B","review":"Good","rating":4},
+				{"product_id":1,"first_name":"A","last_name":"B","review":"Bad","rating":9},
+				{"first_name":"A","last_name":"B","review":"Good","rating":4},
+				null,
+				{"product_id":404,"first_name":"A","last_name":"B","review":"Good","rating":4}
+			]}`,
+			wantStatus: http.StatusMultiStatus,
+			wantItems: []string{
+				dto.BatchItemCreated,
+				dto.BatchItemInvalid,
+				dto.BatchItemInvalid,
+				dto.BatchItemInvalid,
+				dto.BatchItemNotFound,
+			},
+		},
+		{
+			name: "product from path",
+			path: "/products/1/reviews:batch",
+			body: `{"reviews":[
+				{"product_id":404,"first_name":"A","last_name":"B","review":"Good","rating":4},
+				{"first_name":"A","last_name":"B","review":"Good","rating":4}
+			]}`,
+			wantStatus: http.StatusOK,
+			wantItems:  []string{dto.BatchItemCreated, dto.BatchItemCreated},
+		},
+		{
+			name: "unknown product from path",
+			path: "/products/404/reviews:batch",
+			body: `{"reviews":[
+				{"first_name":"A","last_name":"B","review":"Good","rating":4}
+			]}`,
+			wantStatus: http.StatusMultiStatus,
+			wantItems:  []string{dto.BatchItemNotFound},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			testRouter().ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantItems == nil {
+				return
+			}
+
+			var result dto.ReviewBatchResult
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+			require.Len(t, result.Items, len(tt.wantItems))
+
+			failed := 0
+			for i, item := range result.Items {
+				assert.Equal(t, i, item.Index)
+				assert.Equal(t, tt.wantItems[i], item.Status)
+				if item.Status != dto.BatchItemCreated {
+					failed++
+				}
+			}
+			assert.Equal(t, failed, result.Failed)
+			assert.Equal(t, len(tt.wantItems)-failed, result.Created)
+		})
+	}
+}
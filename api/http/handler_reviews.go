@@ -14,6 +14,7 @@ func (s *Server) setupReviewsRouter(r *mux.Router) {
 	r.HandleFunc("/{review_id}", s.handleGetReview()).Methods("GET")
 	r.HandleFunc("", s.handlePostReview()).Methods("POST")
 	r.HandleFunc("/{review_id}", s.handlePutReview()).Methods("PUT")
+	r.HandleFunc("/{review_id}", s.handlePatchReview()).Methods("PATCH")
 	r.HandleFunc("/{review_id}", s.handleDeleteReview()).Methods("DELETE")
 }
 
@@ -39,7 +40,7 @@ func (s *Server) handleListReviews() http.HandlerFunc {
 
 		reviews, err := s.manager.ListProductReviews(r.Context(), productID, offset, limit)
 		if err != nil {
-			http.Error(w, "handleListReviews - ListProductReviews: "+err.Error(), http.StatusInternalServerError)
+			sendError(w, "handleListReviews - ListProductReviews", err)
 			return
 		}
 
@@ -63,7 +64,7 @@ func (s *Server) handleGetReview() http.HandlerFunc {
 
 		review, err := s.manager.GetProductReview(r.Context(), productID, reviewID)
 		if err != nil {
-			http.Error(w, "handleGetReview - GetProductReview: "+err.Error(), http.StatusInternalServerError)
+			sendError(w, "handleGetReview - GetProductReview", err)
 			return
 		}
 
@@ -100,7 +101,7 @@ func (s *Server) handlePostReview() http.HandlerFunc {
 
 		reviewID, err := s.manager.CreateProductReview(r.Context(), productID, &review)
 		if err != nil {
-			http.Error(w, "handlePostReview - CreateProductReview: "+err.Error(), http.StatusInternalServerError)
+			sendError(w, "handlePostReview - CreateProductReview", err)
 			return
 		}
 
@@ -139,7 +140,7 @@ func (s *Server) handlePutReview() http.HandlerFunc {
 		}
 
 		if err := s.manager.UpdateProductReview(r.Context(), productID, reviewID, &review); err != nil {
-			http.Error(w, "handlePutReview - manager: "+err.Error(), http.StatusInternalServerError)
+			sendError(w, "handlePutReview - manager", err)
 			return
 		}
 
@@ -147,6 +148,63 @@ func (s *Server) handlePutReview() http.HandlerFunc {
 	}
 }
 
+func (s *Server) handlePatchReview() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		productID, err := getProductID(r)
+		if err != nil {
+			http.Error(w, "handlePatchReview - getProductID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		reviewID, err := getReviewID(r)
+		if err != nil {
+			http.Error(w, "handlePatchReview - getReviewID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		patch, err := readMergePatch(r)
+		if err != nil {
+			sendPatchError(w, "handlePatchReview - readMergePatch", err)
+			return
+		}
+
+		var invalid error
+		review, err := s.manager.PatchProductReview(r.Context(), productID, reviewID, func(review *dto.Review) error {
+			if err := applyMergePatch(review, patch); err != nil {
+				invalid = fmt.Errorf("applyMergePatch: %w", err)
+				return invalid
+			}
+
+			review.ID = reviewID
+
+			if err := validate.Struct(review); err != nil {
+				invalid = fmt.Errorf("validate: %w", err)
+				return invalid
+			}
+
+			return nil
+		})
+		if invalid != nil {
+			http.Error(w, "handlePatchReview - "+invalid.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err != nil {
+			sendError(w, "handlePatchReview - manager", err)
+			return
+		}
+
+		if review == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		s.sendAsJSON(w, review)
+	}
+}
+
 func (s *Server) handleDeleteReview() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		productID, err := getProductID(r)
@@ -162,7 +220,7 @@ func (s *Server) handleDeleteReview() http.HandlerFunc {
 		}
 
 		if err := s.manager.DeleteProductReview(r.Context(), productID, reviewID); err != nil {
-			http.Error(w, "handleDeleteReview - manager: "+err.Error(), http.StatusInternalServerError)
+			sendError(w, "handleDeleteReview - manager", err)
 			return
 		}
 
@@ -177,6 +177,49 @@ func TestHandlePutReview(t *testing.T) {
 	}
 }
 
+func TestHandlePatchReview(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantBody    string
+	}{
+		{
+			name:        "wrong content type",
+			contentType: "text/plain",
+			body:        `{"rating":4}`,
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "invalid rating",
+			contentType: mergePatchContentType,
+			body:        `{"rating":6}`,
+			wantStatus:  http.StatusBadRequest,
+			wantBody:    "handlePatchReview - validate",
+		},
+		{
+			name:        "valid",
+			contentType: mergePatchContentType + "; charset=utf-8",
+			body:        `{"rating":4,"review":"Good"}`,
+			wantStatus:  http.StatusOK,
+			wantBody:    `{"id":1,"first_name":"Sergej","last_name":"Sizov","review":"Good","rating":4}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/products/1/reviews/1", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			testRouter().ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			require.Contains(t, strings.TrimSpace(rec.Body.String()), tt.wantBody)
+		})
+	}
+}
+
 func TestHandleDeleteReview(t *testing.T) {
 	tests := []struct {
 		name       string
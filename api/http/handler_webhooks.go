@@ -0,0 +1,178 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/lameaux/golang-product-reviews/dto"
+	"github.com/lameaux/golang-product-reviews/model"
+)
+
+func (s *Server) setupWebhooksRouter(r *mux.Router) {
+	r.HandleFunc("", s.handleListWebhooks()).Methods("GET")
+	r.HandleFunc("/{webhook_id}", s.handleGetWebhook()).Methods("GET")
+	r.HandleFunc("", s.handlePostWebhook()).Methods("POST")
+	r.HandleFunc("/{webhook_id}", s.handlePutWebhook()).Methods("PUT")
+	r.HandleFunc("/{webhook_id}", s.handleDeleteWebhook()).Methods("DELETE")
+	r.HandleFunc("/{webhook_id}/deliveries", s.handleListWebhookDeliveries()).Methods("GET")
+}
+
+func (s *Server) handleListWebhooks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		offset, err := getIntQuery(r, "offset", 0)
+		if err != nil {
+			http.Error(w, "handleListWebhooks - invalid offset", http.StatusBadRequest)
+			return
+		}
+
+		limit, err := getIntQuery(r, "limit", 100)
+		if err != nil {
+			http.Error(w, "handleListWebhooks - invalid limit", http.StatusBadRequest)
+			return
+		}
+
+		webhooks, err := s.webhooks.ListWebhooks(r.Context(), offset, limit)
+		if err != nil {
+			sendError(w, "handleListWebhooks - ListWebhooks", err)
+			return
+		}
+
+		s.sendAsJSON(w, webhooks)
+	}
+}
+
+func (s *Server) handleGetWebhook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhookID, err := getWebhookID(r)
+		if err != nil {
+			http.Error(w, "handleGetWebhook - getWebhookID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		webhook, err := s.webhooks.GetWebhook(r.Context(), webhookID)
+		if err != nil {
+			sendError(w, "handleGetWebhook - GetWebhook", err)
+			return
+		}
+
+		if webhook == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		s.sendAsJSON(w, webhook)
+	}
+}
+
+func (s *Server) handlePostWebhook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		var webhook dto.Webhook
+		decoder := json.NewDecoder(r.Body)
+		if err := decoder.Decode(&webhook); err != nil {
+			http.Error(w, "handlePostWebhook - decode: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := validate.Struct(&webhook); err != nil {
+			http.Error(w, "handlePostWebhook - validate: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		webhookID, err := s.webhooks.CreateWebhook(r.Context(), &webhook)
+		if err != nil {
+			sendError(w, "handlePostWebhook - CreateWebhook", err)
+			return
+		}
+
+		location := fmt.Sprintf("/admin/webhooks/%d", webhookID)
+		w.Header().Add("Location", location)
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+func (s *Server) handlePutWebhook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		var webhook dto.Webhook
+		decoder := json.NewDecoder(r.Body)
+		if err := decoder.Decode(&webhook); err != nil {
+			http.Error(w, "handlePutWebhook - decode: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := validate.Struct(&webhook); err != nil {
+			http.Error(w, "handlePutWebhook - validate: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		webhookID, err := getWebhookID(r)
+		if err != nil {
+			http.Error(w, "handlePutWebhook - getWebhookID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := s.webhooks.UpdateWebhook(r.Context(), webhookID, &webhook); err != nil {
+			sendError(w, "handlePutWebhook - manager", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (s *Server) handleDeleteWebhook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhookID, err := getWebhookID(r)
+		if err != nil {
+			http.Error(w, "handleDeleteWebhook - getWebhookID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := s.webhooks.DeleteWebhook(r.Context(), webhookID); err != nil {
+			sendError(w, "handleDeleteWebhook - manager", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) handleListWebhookDeliveries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		offset, err := getIntQuery(r, "offset", 0)
+		if err != nil {
+			http.Error(w, "handleListWebhookDeliveries - invalid offset", http.StatusBadRequest)
+			return
+		}
+
+		limit, err := getIntQuery(r, "limit", 100)
+		if err != nil {
+			http.Error(w, "handleListWebhookDeliveries - invalid limit", http.StatusBadRequest)
+			return
+		}
+
+		webhookID, err := getWebhookID(r)
+		if err != nil {
+			http.Error(w, "handleListWebhookDeliveries - getWebhookID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		deliveries, err := s.webhooks.ListDeliveries(r.Context(), webhookID, offset, limit)
+		if err != nil {
+			sendError(w, "handleListWebhookDeliveries - ListDeliveries", err)
+			return
+		}
+
+		s.sendAsJSON(w, deliveries)
+	}
+}
+
+func getWebhookID(r *http.Request) (model.ID, error) {
+	return strconv.Atoi(mux.Vars(r)["webhook_id"])
+}
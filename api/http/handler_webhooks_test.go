@@ -0,0 +1,183 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleListWebhooks(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/admin/webhooks", nil)
+	rec := httptest.NewRecorder()
+
+	testRouter().ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, `[{"id":1,"url":"https://partner.example.com/hooks","events":["create"],"paused":false}]`, strings.TrimSpace(rec.Body.String()))
+}
+
+func TestHandleGetWebhook(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         int
+		wantStatus int
+	}{
+		{
+			name:       "invalid id",
+			id:         404,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "valid id",
+			id:         1,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/admin/webhooks/%d", tt.id), nil)
+			rec := httptest.NewRecorder()
+
+			testRouter().ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandlePostWebhook(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		wantStatus   int
+		wantBody     string
+		wantLocation string
+	}{
+		{
+			name:       "missing body",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "handlePostWebhook - decode",
+		},
+		{
+			name:       "invalid url",
+			body:       `{"url":"not a url","secret":"0123456789abcdef"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "handlePostWebhook - validate",
+		},
+		{
+			name:       "short secret",
+			body:       `{"url":"https://partner.example.com/hooks","secret":"short"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "handlePostWebhook - validate",
+		},
+		{
+			name:       "unknown event",
+			body:       `{"url":"https://partner.example.com/hooks","events":["rename"],"secret":"0123456789abcdef"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "handlePostWebhook - validate",
+		},
+		{
+			name:       "invalid subject pattern",
+			body:       `{"url":"https://partner.example.com/hooks","events":["products.>.created"],"secret":"0123456789abcdef"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "handlePostWebhook - validate",
+		},
+		{
+			name:         "valid",
+			body:         `{"url":"https://partner.example.com/hooks","events":["create","delete","products.*.updated"],"secret":"0123456789abcdef"}`,
+			wantStatus:   http.StatusCreated,
+			wantLocation: "/admin/webhooks/2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/admin/webhooks", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+
+			testRouter().ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			require.Contains(t, strings.TrimSpace(rec.Body.String()), tt.wantBody)
+			require.Equal(t, tt.wantLocation, rec.Header().Get("Location"))
+		})
+	}
+}
+
+func TestHandlePutWebhook(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         int
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "empty body",
+			id:         1,
+			body:       "{}",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "not found",
+			id:         404,
+			body:       `{"url":"https://partner.example.com/hooks","secret":"0123456789abcdef","paused":true}`,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "valid",
+			id:         1,
+			body:       `{"url":"https://partner.example.com/hooks","secret":"0123456789abcdef","paused":true}`,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/admin/webhooks/%d", tt.id), strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+
+			testRouter().ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandleDeleteWebhook(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         int
+		wantStatus int
+	}{
+		{name: "not found", id: 404, wantStatus: http.StatusNotFound},
+		{name: "valid", id: 1, wantStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/admin/webhooks/%d", tt.id), nil)
+			rec := httptest.NewRecorder()
+
+			testRouter().ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandleListWebhookDeliveries(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/admin/webhooks/1/deliveries", nil)
+	rec := httptest.NewRecorder()
+
+	testRouter().ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, `[{"id":1,"webhook_id":1,"event_id":"e1","status":"delivered","attempts":1,`+
+		`"next_attempt_at":"2025-01-02T03:04:05Z","created_at":"2025-01-02T03:04:05Z","updated_at":"2025-01-02T03:04:05Z"}]`,
+		strings.TrimSpace(rec.Body.String()))
+}
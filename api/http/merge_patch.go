@@ -0,0 +1,115 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+const mergePatchContentType = "application/merge-patch+json"
+
+var errUnsupportedMediaType = errors.New("unsupported media type, want " + mergePatchContentType)
+
+// readMergePatch reads a JSON Merge Patch (RFC 7396) from the request body.
+func readMergePatch(r *http.Request) (any, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != mergePatchContentType {
+		return nil, errUnsupportedMediaType
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	patch, err := decodeJSON(body)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	return patch, nil
+}
+
+// applyMergePatch merges patch into the JSON representation of target
+// and decodes the result back into it. Members set to null are removed,
+// so the corresponding fields end up empty.
+func applyMergePatch[T any](target *T, patch any) error {
+	doc, err := json.Marshal(target)
+	if err != nil {
+		return fmt.Errorf("encode target: %w", err)
+	}
+
+	value, err := decodeJSON(doc)
+	if err != nil {
+		return fmt.Errorf("decode target: %w", err)
+	}
+
+	merged, err := json.Marshal(mergePatch(value, patch))
+	if err != nil {
+		return fmt.Errorf("encode result: %w", err)
+	}
+
+	var result T
+	if err := json.Unmarshal(merged, &result); err != nil {
+		return fmt.Errorf("decode result: %w", err)
+	}
+
+	*target = result
+
+	return nil
+}
+
+// mergePatch implements the MergePatch function of RFC 7396.
+func mergePatch(target any, patch any) any {
+	members, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	result, ok := target.(map[string]any)
+	if !ok {
+		result = map[string]any{}
+	}
+
+	for name, value := range members {
+		if value == nil {
+			delete(result, name)
+			continue
+		}
+
+		result[name] = mergePatch(result[name], value)
+	}
+
+	return result
+}
+
+// decodeJSON keeps numbers as json.Number, so large integers survive the merge.
+func decodeJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	if decoder.More() {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+
+	return value, nil
+}
+
+// sendPatchError responds with 415 to other media types and with 400 to invalid patches.
+func sendPatchError(w http.ResponseWriter, msg string, err error) {
+	if errors.Is(err, errUnsupportedMediaType) {
+		http.Error(w, msg+": "+err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	http.Error(w, msg+": "+err.Error(), http.StatusBadRequest)
+}
@@ -0,0 +1,63 @@
+package http
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Examples from Appendix A of RFC 7396.
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		target string
+		patch  string
+		want   string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target+" "+tt.patch, func(t *testing.T) {
+			target, err := decodeJSON([]byte(tt.target))
+			require.NoError(t, err)
+
+			patch, err := decodeJSON([]byte(tt.patch))
+			require.NoError(t, err)
+
+			got, err := json.Marshal(mergePatch(target, patch))
+			require.NoError(t, err)
+
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestApplyMergePatch_LargeNumbers(t *testing.T) {
+	target := struct {
+		Price int64  `json:"price"`
+		Name  string `json:"name"`
+	}{Price: 1, Name: "P1"}
+
+	patch, err := decodeJSON([]byte(`{"price":9007199254740993}`))
+	require.NoError(t, err)
+
+	require.NoError(t, applyMergePatch(&target, patch))
+	assert.Equal(t, int64(9007199254740993), target.Price)
+	assert.Equal(t, "P1", target.Name)
+}
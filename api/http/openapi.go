@@ -0,0 +1,234 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// openAPISpec describes every route of CreateRouter, TestOpenAPIRoutes fails if they drift apart.
+//
+//go:embed openapi/openapi.json
+var openAPISpec []byte
+
+// swaggerInitializer points the bundled Swagger UI to /openapi.json.
+//
+//go:embed openapi/swagger-initializer.js
+var swaggerInitializer []byte
+
+// Validation of requests and responses against the OpenAPI document.
+type Validation byte
+
+const (
+	ValidationOff Validation = iota
+	// ValidationRequests answers requests that don't match the document with 400.
+	ValidationRequests
+	// ValidationAll also logs responses that don't match the document, they are sent unchanged.
+	ValidationAll
+)
+
+var validationNames = map[Validation]string{
+	ValidationOff:      "off",
+	ValidationRequests: "requests",
+	ValidationAll:      "all",
+}
+
+func (v Validation) String() string {
+	if name, ok := validationNames[v]; ok {
+		return name
+	}
+	return fmt.Sprintf("validation(%d)", byte(v))
+}
+
+// ParseValidation returns the validation for off, requests or all.
+func ParseValidation(name string) (Validation, error) {
+	for v, n := range validationNames {
+		if n == name {
+			return v, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown validation %q", name)
+}
+
+func loadOpenAPI() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(openAPISpec)
+	if err != nil {
+		return nil, fmt.Errorf("load: %w", err)
+	}
+
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("validate: %w", err)
+	}
+
+	return doc, nil
+}
+
+// SetValidation validates requests and responses of the router against the OpenAPI document.
+func (s *Server) SetValidation(validation Validation) error {
+	if validation == ValidationOff {
+		s.validator = nil
+		return nil
+	}
+
+	doc, err := loadOpenAPI()
+	if err != nil {
+		return fmt.Errorf("loadOpenAPI: %w", err)
+	}
+
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return fmt.Errorf("gorillamux.NewRouter: %w", err)
+	}
+
+	s.validator = &openAPIValidator{
+		router:    router,
+		responses: validation == ValidationAll,
+		logger:    s.logger,
+	}
+
+	return nil
+}
+
+func (s *Server) setupOpenAPIRouter(r *mux.Router) {
+	r.HandleFunc("/openapi.json", s.handleOpenAPI()).Methods("GET")
+	r.Handle("/docs", http.RedirectHandler("/docs/", http.StatusMovedPermanently)).Methods("GET")
+	r.PathPrefix("/docs/").Handler(http.StripPrefix("/docs/", swaggerUI())).Methods("GET")
+}
+
+func (s *Server) handleOpenAPI() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(openAPISpec); err != nil {
+			s.logger.Error().Err(err).Msg("write openapi failed")
+		}
+	}
+}
+
+// swaggerUI serves the bundled Swagger UI with the initializer of the API.
+func swaggerUI() http.Handler {
+	files := http.FileServer(http.FS(swaggerFiles.FS))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "swagger-initializer.js" {
+			w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+			_, _ = w.Write(swaggerInitializer)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
+
+type openAPIValidator struct {
+	router    routers.Router
+	responses bool
+	logger    *zerolog.Logger
+}
+
+var validationOptions = &openapi3filter.Options{
+	// handlers ignore the ids in bodies
+	ExcludeReadOnlyValidations: true,
+	// requests are passed on as they were sent
+	SkipSettingDefaults: true,
+	// tokens are checked by authMiddleware
+	AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+}
+
+// middleware answers requests that don't match the document with 400 and logs
+// mismatching responses. Routes that aren't in the document pass through.
+func (v *openAPIValidator) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := v.router.FindRoute(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    validationOptions,
+		}
+		if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+			http.Error(w, "openapi - ValidateRequest: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if !v.responses || streams(route) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		out := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 rec.status,
+			Header:                 rec.header,
+			Body:                   io.NopCloser(bytes.NewReader(rec.body.Bytes())),
+			Options:                validationOptions,
+		}
+		if err := openapi3filter.ValidateResponse(r.Context(), out); err != nil {
+			v.logger.Warn().Err(err).
+				Str("method", r.Method).
+				Str("path", route.Path).
+				Int("status", rec.status).
+				Msg("response doesn't match openapi")
+		}
+
+		rec.writeTo(w)
+	})
+}
+
+// streams reports if the operation responds with Server-Sent Events, they can't be buffered.
+func streams(route *routers.Route) bool {
+	ok := route.Operation.Responses.Status(http.StatusOK)
+	return ok != nil && ok.Value != nil && ok.Value.Content.Get("text/event-stream") != nil
+}
+
+// bufferedResponse keeps a response until it is validated.
+type bufferedResponse struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.wroteHeader {
+		return
+	}
+	b.status = status
+	b.wroteHeader = true
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	b.wroteHeader = true
+	return b.body.Write(p)
+}
+
+func (b *bufferedResponse) writeTo(w http.ResponseWriter) {
+	for key, values := range b.header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(b.status)
+	_, _ = w.Write(b.body.Bytes())
+}
@@ -0,0 +1,306 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gorilla/mux"
+	"github.com/lameaux/golang-product-reviews/dto"
+	"github.com/lameaux/golang-product-reviews/feed"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+// undocumentedRoutes are served by the router but not described in the document.
+var undocumentedRoutes = []string{"/docs", "/docs/"}
+
+func TestOpenAPIRoutes(t *testing.T) {
+	doc, err := loadOpenAPI()
+	require.NoError(t, err)
+
+	var routes []string
+	err = testRouter().Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			// subrouter prefixes don't have methods
+			return nil
+		}
+
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+
+		if slices.Contains(undocumentedRoutes, path) {
+			return nil
+		}
+
+		for _, method := range methods {
+			routes = append(routes, method+" "+path)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	var operations []string
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			operations = append(operations, method+" "+path)
+		}
+	}
+
+	require.ElementsMatch(t, routes, operations, "routes of CreateRouter and operations of openapi.json differ")
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	doc, err := loadOpenAPI()
+	require.NoError(t, err)
+
+	schemas := map[string]any{
+		"Product":           dto.Product{},
+		"ProductWithRating": dto.ProductWithRating{},
+		"ProductRating":     dto.ProductRating{},
+		"Review":            dto.Review{},
+		"BatchReview":       dto.BatchReview{},
+		"ReviewBatch":       dto.ReviewBatch{},
+		"ReviewBatchItem":   dto.ReviewBatchItem{},
+		"ReviewBatchResult": dto.ReviewBatchResult{},
+		"Reviewer":          dto.Reviewer{},
+		"ReviewerReview":    dto.ReviewerReview{},
+		"ReviewerExport":    dto.ReviewerExport{},
+		"ErasureRequest":    dto.ErasureRequest{},
+		"ErasureResult":     dto.ErasureResult{},
+		"Webhook":           dto.Webhook{},
+		"WebhookDelivery":   dto.WebhookDelivery{},
+		"CacheEntry":        dto.CacheEntry{},
+		"CacheEntries":      dto.CacheEntries{},
+		"CacheFlush":        dto.CacheFlush{},
+		"CacheWarmRequest":  dto.CacheWarmRequest{},
+		"CacheWarmResult":   dto.CacheWarmResult{},
+	}
+
+	for name, value := range schemas {
+		t.Run(name, func(t *testing.T) {
+			schema := doc.Components.Schemas[name]
+			require.NotNil(t, schema, "schema is missing")
+
+			require.ElementsMatch(t, jsonFields(reflect.TypeOf(value)), schemaProperties(schema.Value))
+		})
+	}
+}
+
+// jsonFields returns the JSON names of the fields of a struct, with the fields of embedded structs.
+func jsonFields(t reflect.Type) []string {
+	var fields []string
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if field.Anonymous {
+			fields = append(fields, jsonFields(field.Type)...)
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+// schemaProperties returns the properties of a schema, with the properties of allOf schemas.
+func schemaProperties(schema *openapi3.Schema) []string {
+	var properties []string
+	for name := range schema.Properties {
+		properties = append(properties, name)
+	}
+	for _, ref := range schema.AllOf {
+		properties = append(properties, schemaProperties(ref.Value)...)
+	}
+	return properties
+}
+
+func TestParseValidation(t *testing.T) {
+	for _, v := range []Validation{ValidationOff, ValidationRequests, ValidationAll} {
+		parsed, err := ParseValidation(v.String())
+		require.NoError(t, err)
+		require.Equal(t, v, parsed)
+	}
+
+	_, err := ParseValidation("strict")
+	require.Error(t, err)
+}
+
+func TestHandleOpenAPI(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		wantStatus   int
+		wantContains string
+	}{
+		{
+			name:         "document",
+			path:         "/openapi.json",
+			wantStatus:   http.StatusOK,
+			wantContains: `"openapi": "3.1.0"`,
+		},
+		{
+			name:       "docs redirect",
+			path:       "/docs",
+			wantStatus: http.StatusMovedPermanently,
+		},
+		{
+			name:         "swagger ui",
+			path:         "/docs/",
+			wantStatus:   http.StatusOK,
+			wantContains: "swagger-ui",
+		},
+		{
+			name:         "swagger initializer",
+			path:         "/docs/swagger-initializer.js",
+			wantStatus:   http.StatusOK,
+			wantContains: `url: "/openapi.json"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+			testRouter().ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			require.Contains(t, rec.Body.String(), tt.wantContains)
+		})
+	}
+}
+
+func TestValidationMiddleware(t *testing.T) {
+	var logs bytes.Buffer
+	logger := zerolog.New(&logs).Level(zerolog.WarnLevel)
+
+	server := New(0, &logger, stubProductManager(), stubWebhookManager(), stubCacheAdmin(), feed.NewBroker(10))
+	require.NoError(t, server.SetValidation(ValidationAll))
+	router := server.CreateRouter()
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		contentType  string
+		body         string
+		wantStatus   int
+		wantContains string
+	}{
+		{name: "health", method: http.MethodGet, path: "/health", wantStatus: http.StatusOK},
+		{name: "metrics", method: http.MethodGet, path: "/metrics", wantStatus: http.StatusOK},
+		{name: "openapi", method: http.MethodGet, path: "/openapi.json", wantStatus: http.StatusOK},
+		{name: "swagger ui", method: http.MethodGet, path: "/docs/", wantStatus: http.StatusOK},
+		{name: "list products", method: http.MethodGet, path: "/products?offset=0&limit=10", wantStatus: http.StatusOK},
+		{name: "get product", method: http.MethodGet, path: "/products/1", wantStatus: http.StatusOK},
+		{name: "missing product", method: http.MethodGet, path: "/products/404", wantStatus: http.StatusNotFound},
+		{
+			name:         "invalid product id",
+			method:       http.MethodGet,
+			path:         "/products/abc",
+			wantStatus:   http.StatusBadRequest,
+			wantContains: "openapi - ValidateRequest",
+		},
+		{
+			name:         "invalid limit",
+			method:       http.MethodGet,
+			path:         "/products?limit=ten",
+			wantStatus:   http.StatusBadRequest,
+			wantContains: "openapi - ValidateRequest",
+		},
+		{
+			name:        "create product",
+			method:      http.MethodPost,
+			path:        "/products",
+			contentType: "application/json",
+			body:        `{"name":"P2","description":"P2 desc","price":200}`,
+			wantStatus:  http.StatusCreated,
+		},
+		{
+			name:         "create product without name",
+			method:       http.MethodPost,
+			path:         "/products",
+			contentType:  "application/json",
+			body:         `{"description":"P2 desc","price":200}`,
+			wantStatus:   http.StatusBadRequest,
+			wantContains: "openapi - ValidateRequest",
+		},
+		{
+			name:        "patch product",
+			method:      http.MethodPatch,
+			path:        "/products/1",
+			contentType: mergePatchContentType,
+			body:        `{"price":150}`,
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:         "patch product with json",
+			method:       http.MethodPatch,
+			path:         "/products/1",
+			contentType:  "application/json",
+			body:         `{"price":150}`,
+			wantStatus:   http.StatusBadRequest,
+			wantContains: "openapi - ValidateRequest",
+		},
+		{name: "list reviews", method: http.MethodGet, path: "/products/1/reviews", wantStatus: http.StatusOK},
+		{name: "get review", method: http.MethodGet, path: "/products/1/reviews/1", wantStatus: http.StatusOK},
+		{
+			name:         "create review with rating out of range",
+			method:       http.MethodPost,
+			path:         "/products/1/reviews",
+			contentType:  "application/json",
+			body:         `{"first_name":"A","last_name":"B","review":"Good","rating":6}`,
+			wantStatus:   http.StatusBadRequest,
+			wantContains: "openapi - ValidateRequest",
+		},
+		{
+			name:        "review batch",
+			method:      http.MethodPost,
+			path:        "/reviews:batch",
+			contentType: "application/json",
+			body:        `{"reviews":[{"product_id":1,"first_name":"A","last_name":"B","review":"Good","rating":4},{"product_id":1,"rating":9}]}`,
+			wantStatus:  http.StatusMultiStatus,
+		},
+		{
+			name:         "empty review batch",
+			method:       http.MethodPost,
+			path:         "/reviews:batch",
+			contentType:  "application/json",
+			body:         `{"reviews":[]}`,
+			wantStatus:   http.StatusBadRequest,
+			wantContains: "openapi - ValidateRequest",
+		},
+		{name: "list webhooks", method: http.MethodGet, path: "/admin/webhooks", wantStatus: http.StatusOK},
+		{name: "get webhook", method: http.MethodGet, path: "/admin/webhooks/1", wantStatus: http.StatusOK},
+		{name: "webhook deliveries", method: http.MethodGet, path: "/admin/webhooks/1/deliveries", wantStatus: http.StatusOK},
+		{name: "product cache", method: http.MethodGet, path: "/admin/cache/products/1", wantStatus: http.StatusOK},
+		{name: "warm cache without body", method: http.MethodPost, path: "/admin/cache/warm", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+			require.Contains(t, rec.Body.String(), tt.wantContains)
+		})
+	}
+
+	require.Empty(t, logs.String(), "responses don't match openapi.json")
+}
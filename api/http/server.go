@@ -3,6 +3,7 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -10,31 +11,66 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
+	"github.com/lameaux/golang-product-reviews/cache"
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/lameaux/golang-product-reviews/feed"
+	"github.com/lameaux/golang-product-reviews/lock"
 	"github.com/lameaux/golang-product-reviews/model"
 	"github.com/lameaux/golang-product-reviews/productmanager"
+	"github.com/lameaux/golang-product-reviews/webhook"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
 )
 
 var (
-	validate = validator.New(validator.WithRequiredStructEnabled())
+	validate = newValidator()
 )
 
+// lockedRetryAfter is sent in Retry-After when a lock can't be acquired, in seconds.
+const lockedRetryAfter = "1"
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// webhook filters are actions or subject patterns like products.*.reviews.>
+	_ = v.RegisterValidation("event_filter", func(fl validator.FieldLevel) bool {
+		return events.ValidFilter(fl.Field().String())
+	})
+
+	return v
+}
+
 type Server struct {
-	srv     *http.Server
-	port    int
-	logger  *zerolog.Logger
-	manager productmanager.Manager
+	srv      *http.Server
+	port     int
+	logger   *zerolog.Logger
+	manager  productmanager.Manager
+	webhooks webhook.Manager
+	// cacheAdmin serves the cache inspector under /admin/cache
+	cacheAdmin cache.Admin
+	feed       *feed.Broker
+	// validator checks requests and responses against the OpenAPI document if set
+	validator *openAPIValidator
+	// tokens authenticate callers and authorizer guards /admin if set
+	tokens     *Tokens
+	authorizer productmanager.Authorizer
 }
 
 func New(
 	port int,
 	logger *zerolog.Logger,
 	manager productmanager.Manager,
+	webhooks webhook.Manager,
+	cacheAdmin cache.Admin,
+	feed *feed.Broker,
 ) *Server {
 	return &Server{
-		port:    port,
-		logger:  logger,
-		manager: manager,
+		port:       port,
+		logger:     logger,
+		manager:    manager,
+		webhooks:   webhooks,
+		cacheAdmin: cacheAdmin,
+		feed:       feed,
 		srv: &http.Server{
 			Addr:         fmt.Sprintf(":%d", port),
 			WriteTimeout: time.Second * 15,
@@ -46,6 +82,10 @@ func New(
 
 func (s *Server) Serve() error {
 	s.srv.Handler = s.CreateRouter()
+	if s.feed != nil {
+		// ends event streams, otherwise shutdown waits for them
+		s.srv.RegisterOnShutdown(s.feed.Close)
+	}
 
 	s.logger.Info().Int("port", s.port).Msg("starting http server")
 	err := s.srv.ListenAndServe()
@@ -60,7 +100,16 @@ func (s *Server) Serve() error {
 func (s *Server) CreateRouter() *mux.Router {
 	r := mux.NewRouter()
 	r.Use(s.loggingMiddleware)
+	if s.tokens != nil {
+		r.Use(s.authMiddleware)
+	}
+	r.Use(actorMiddleware)
+	if s.validator != nil {
+		r.Use(s.validator.middleware)
+	}
 	r.HandleFunc("/health", s.handleHealth()).Methods("GET")
+	r.Handle("/metrics", promhttp.Handler()).Methods("GET")
+	s.setupOpenAPIRouter(r)
 
 	products := r.PathPrefix("/products").Subrouter()
 	s.setupProductsRouter(products)
@@ -68,6 +117,21 @@ func (s *Server) CreateRouter() *mux.Router {
 	reviews := products.PathPrefix("/{product_id}/reviews").Subrouter()
 	s.setupReviewsRouter(reviews)
 
+	s.setupEventsRouter(r, products)
+	s.setupReviewBatchRouter(r, products)
+
+	admin := r.PathPrefix("/admin").Subrouter()
+	if s.authorizer != nil {
+		admin.Use(s.adminMiddleware)
+	}
+	s.setupAdminRouter(admin)
+
+	webhooks := admin.PathPrefix("/webhooks").Subrouter()
+	s.setupWebhooksRouter(webhooks)
+
+	cacheRouter := admin.PathPrefix("/cache").Subrouter()
+	s.setupCacheRouter(cacheRouter)
+
 	return r
 }
 
@@ -87,6 +151,17 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// actorMiddleware attaches the X-Actor header to change events. It is an audit label
+// the client chooses, authorization uses the principal of authMiddleware.
+func actorMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if actor := r.Header.Get("X-Actor"); actor != "" {
+			r = r.WithContext(events.WithActor(r.Context(), actor))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (s *Server) sendAsJSON(w http.ResponseWriter, response interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -95,6 +170,25 @@ func (s *Server) sendAsJSON(w http.ResponseWriter, response interface{}) {
 	}
 }
 
+// sendError responds with 503 if the lock of the product is contended, so clients
+// retry later, with 403 to calls the manager doesn't authorize, with 504 to calls
+// that ran out of time and with 500 otherwise.
+func sendError(w http.ResponseWriter, msg string, err error) {
+	switch {
+	case errors.Is(err, lock.ErrLocked):
+		w.Header().Set("Retry-After", lockedRetryAfter)
+		http.Error(w, msg+": "+err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, productmanager.ErrForbidden):
+		http.Error(w, msg+": "+err.Error(), http.StatusForbidden)
+	case errors.Is(err, webhook.ErrNotFound):
+		http.Error(w, msg+": "+err.Error(), http.StatusNotFound)
+	case errors.Is(err, context.DeadlineExceeded):
+		http.Error(w, msg+": "+err.Error(), http.StatusGatewayTimeout)
+	default:
+		http.Error(w, msg+": "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
 func getProductID(r *http.Request) (model.ID, error) {
 	productID, err := strconv.Atoi(mux.Vars(r)["product_id"])
 	if err != nil {
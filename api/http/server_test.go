@@ -1,14 +1,27 @@
 package http
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
 	"github.com/gorilla/mux"
+	"github.com/lameaux/golang-product-reviews/cache"
 	"github.com/lameaux/golang-product-reviews/dto"
+	"github.com/lameaux/golang-product-reviews/feed"
+	"github.com/lameaux/golang-product-reviews/lock"
 	"github.com/lameaux/golang-product-reviews/productmanager"
+	"github.com/lameaux/golang-product-reviews/webhook"
 	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
 )
 
 func testRouter() *mux.Router {
-	server := New(0, &log.Logger, stubProductManager())
+	server := New(0, &log.Logger, stubProductManager(), stubWebhookManager(), stubCacheAdmin(), feed.NewBroker(10))
 	return server.CreateRouter()
 }
 
@@ -36,3 +49,60 @@ func stubProductManager() *productmanager.StubManager {
 		},
 	}
 }
+
+func stubCacheAdmin() *cache.StubAdmin {
+	return &cache.StubAdmin{
+		Generation: 3,
+		Entries: []cache.Entry{
+			{Key: "products:1:generation", Kind: cache.KindGeneration, TTL: -1},
+			{Key: "products:1:3:product", Kind: cache.KindValue, TTL: time.Hour, SoftTTL: 5 * time.Minute},
+			{Key: "products:1:3:review:2", Kind: cache.KindTombstone, TTL: 30 * time.Second},
+		},
+	}
+}
+
+func stubWebhookManager() *webhook.StubManager {
+	return &webhook.StubManager{
+		Webhooks: []*dto.Webhook{
+			{
+				ID:     1,
+				URL:    "https://partner.example.com/hooks",
+				Events: []string{"create"},
+			},
+		},
+		Deliveries: []*dto.WebhookDelivery{
+			{
+				ID:            1,
+				WebhookID:     1,
+				EventID:       "e1",
+				Status:        "delivered",
+				Attempts:      1,
+				NextAttemptAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+				CreatedAt:     time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+				UpdatedAt:     time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			},
+		},
+	}
+}
+
+func TestSendError(t *testing.T) {
+	tests := []struct {
+		err        error
+		wantStatus int
+	}{
+		{lock.ErrLocked, http.StatusServiceUnavailable},
+		{fmt.Errorf("authorize: %w", productmanager.ErrForbidden), http.StatusForbidden},
+		{fmt.Errorf("dao.UpdateWebhook: %w", webhook.ErrNotFound), http.StatusNotFound},
+		{context.DeadlineExceeded, http.StatusGatewayTimeout},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			sendError(rec, "test", tt.err)
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
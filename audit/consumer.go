@@ -0,0 +1,198 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/lameaux/golang-product-reviews/notifier"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+)
+
+const ConsumerName = "audit"
+
+const (
+	ackWait       = 30 * time.Second
+	maxDeliver    = 10
+	recordTimeout = 5 * time.Second
+	replayBatch   = 100
+	replayMaxWait = time.Second
+)
+
+// ReplayFrom is a starting point in the stream history,
+// either a stream sequence or a point in time.
+type ReplayFrom struct {
+	Sequence uint64
+	Time     time.Time
+}
+
+func ParseReplayFrom(val string) (*ReplayFrom, error) {
+	if seq, err := strconv.ParseUint(val, 10, 64); err == nil {
+		if seq == 0 {
+			return nil, errors.New("stream sequence starts at 1")
+		}
+		return &ReplayFrom{Sequence: seq}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		return nil, fmt.Errorf("expected stream sequence or RFC 3339 time: %w", err)
+	}
+
+	return &ReplayFrom{Time: t}, nil
+}
+
+// Consumer feeds messages from the reviews stream into the Recorder.
+type Consumer struct {
+	logger   *zerolog.Logger
+	js       jetstream.JetStream
+	recorder *Recorder
+}
+
+func NewConsumer(logger *zerolog.Logger, js jetstream.JetStream, recorder *Recorder) *Consumer {
+	return &Consumer{logger: logger, js: js, recorder: recorder}
+}
+
+// Run consumes the stream with a durable pull consumer until ctx is done.
+// Messages are acked once stored and redelivered by the server otherwise,
+// so nothing published while the audit service is down is lost.
+func (c *Consumer) Run(ctx context.Context) error {
+	cons, err := c.js.CreateOrUpdateConsumer(ctx, notifier.StreamName, jetstream.ConsumerConfig{
+		Durable:       ConsumerName,
+		FilterSubject: events.SubjectAll,
+		DeliverPolicy: jetstream.DeliverAllPolicy,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       ackWait,
+		MaxDeliver:    maxDeliver,
+	})
+	if err != nil {
+		return fmt.Errorf("CreateOrUpdateConsumer: %w", err)
+	}
+
+	cc, err := cons.Consume(func(msg jetstream.Msg) {
+		c.handle(ctx, msg)
+	})
+	if err != nil {
+		return fmt.Errorf("Consume: %w", err)
+	}
+	defer cc.Stop()
+
+	<-ctx.Done()
+
+	return nil
+}
+
+func (c *Consumer) handle(ctx context.Context, msg jetstream.Msg) {
+	meta, err := msg.Metadata()
+	if err != nil {
+		c.logger.Error().Err(err).Msg("invalid message metadata")
+		c.term(msg)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, recordTimeout)
+	defer cancel()
+
+	err = c.recorder.Record(ctx, meta.Sequence.Stream, meta.Timestamp, msg.Data())
+	switch {
+	case errors.Is(err, events.ErrInvalidEvent):
+		// redelivery will not fix a malformed message
+		c.logger.Error().Err(err).Uint64("seq", meta.Sequence.Stream).Str("data", string(msg.Data())).Msg("record failed")
+		c.term(msg)
+	case err != nil:
+		c.logger.Warn().Err(err).Uint64("seq", meta.Sequence.Stream).Uint64("delivered", meta.NumDelivered).Msg("record failed, will retry")
+		if err := msg.Nak(); err != nil {
+			c.logger.Error().Err(err).Msg("nak failed")
+		}
+	default:
+		if err := msg.Ack(); err != nil {
+			c.logger.Error().Err(err).Msg("ack failed")
+		}
+	}
+}
+
+func (c *Consumer) term(msg jetstream.Msg) {
+	if err := msg.Term(); err != nil {
+		c.logger.Error().Err(err).Msg("term failed")
+	}
+}
+
+// Replay records the stream history starting at from up to the last message
+// present when the replay started. It returns the number of recorded messages.
+func (c *Consumer) Replay(ctx context.Context, from *ReplayFrom) (int, error) {
+	stream, err := c.js.Stream(ctx, notifier.StreamName)
+	if err != nil {
+		return 0, fmt.Errorf("Stream: %w", err)
+	}
+
+	info, err := stream.Info(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("Info: %w", err)
+	}
+
+	lastSeq := info.State.LastSeq
+	if lastSeq == 0 || from.Sequence > lastSeq {
+		return 0, nil
+	}
+
+	cfg := jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{events.SubjectAll},
+		DeliverPolicy:  jetstream.DeliverByStartSequencePolicy,
+		OptStartSeq:    from.Sequence,
+	}
+	if from.Sequence == 0 {
+		cfg.DeliverPolicy = jetstream.DeliverByStartTimePolicy
+		cfg.OptStartSeq = 0
+		cfg.OptStartTime = &from.Time
+	}
+
+	cons, err := stream.OrderedConsumer(ctx, cfg)
+	if err != nil {
+		return 0, fmt.Errorf("OrderedConsumer: %w", err)
+	}
+
+	count := 0
+	for {
+		batch, err := cons.Fetch(replayBatch, jetstream.FetchMaxWait(replayMaxWait))
+		if err != nil {
+			return count, fmt.Errorf("Fetch: %w", err)
+		}
+
+		received := 0
+		for msg := range batch.Messages() {
+			received++
+
+			meta, err := msg.Metadata()
+			if err != nil {
+				return count, fmt.Errorf("Metadata: %w", err)
+			}
+
+			err = c.recorder.Record(ctx, meta.Sequence.Stream, meta.Timestamp, msg.Data())
+			switch {
+			case errors.Is(err, events.ErrInvalidEvent):
+				c.logger.Warn().Err(err).Uint64("seq", meta.Sequence.Stream).Msg("replay skipped message")
+			case err != nil:
+				return count, fmt.Errorf("Record: %w", err)
+			default:
+				count++
+			}
+
+			if meta.Sequence.Stream >= lastSeq {
+				return count, nil
+			}
+		}
+
+		if err := batch.Error(); err != nil {
+			return count, fmt.Errorf("Fetch: %w", err)
+		}
+
+		// nothing left after the start time
+		if received == 0 {
+			return count, nil
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/lameaux/golang-product-reviews/notifier"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func runJetStream(t *testing.T) jetstream.JetStream {
+	t.Helper()
+
+	ns, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+
+	go ns.Start()
+	t.Cleanup(ns.Shutdown)
+	require.True(t, ns.ReadyForConnections(5*time.Second))
+
+	nc, err := nats.Connect(ns.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+
+	_, err = notifier.EnsureStream(t.Context(), js, time.Hour)
+	require.NoError(t, err)
+
+	return js
+}
+
+func recordedSeqs(dao *mockedAuditDAO) []uint64 {
+	var seqs []uint64
+	for _, call := range dao.Calls {
+		seqs = append(seqs, call.Arguments.Get(1).(*model.AuditEvent).StreamSeq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	return seqs
+}
+
+func TestConsumer_Run(t *testing.T) {
+	js := runJetStream(t)
+
+	recorded := make(chan uint64, 10)
+
+	dao := new(mockedAuditDAO)
+	dao.On("CreateAuditEvent", mock.Anything, mock.Anything).Return(errors.New("db down")).Once()
+	dao.On("CreateAuditEvent", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		recorded <- args.Get(1).(*model.AuditEvent).StreamSeq
+	})
+
+	// published while the audit service is down
+	n := notifier.NewNATS(&log.Logger, js)
+	n.Notify(t.Context(), events.New(t.Context(), events.ActionCreate, 1, 1))
+	_, err := js.Publish(t.Context(), "products.1.created", []byte(`not json`))
+	require.NoError(t, err)
+	n.Notify(t.Context(), events.New(t.Context(), events.ActionCreate, 1, 2))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	consumer := NewConsumer(&log.Logger, js, NewRecorder(&log.Logger, dao))
+	go func() {
+		assert.NoError(t, consumer.Run(ctx))
+	}()
+
+	var seqs []uint64
+	for len(seqs) < 2 {
+		select {
+		case seq := <-recorded:
+			seqs = append(seqs, seq)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timeout, recorded: %v", seqs)
+		}
+	}
+
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	assert.Equal(t, []uint64{1, 3}, seqs)
+}
+
+func TestConsumer_Replay(t *testing.T) {
+	js := runJetStream(t)
+
+	n := notifier.NewNATS(&log.Logger, js)
+	n.Notify(t.Context(), events.New(t.Context(), events.ActionCreate, 1, 1))
+	n.Notify(t.Context(), events.New(t.Context(), events.ActionUpdate, 1, 1))
+	n.Notify(t.Context(), events.New(t.Context(), events.ActionDelete, 1, 1))
+
+	tests := []struct {
+		name      string
+		from      *ReplayFrom
+		wantCount int
+		wantSeqs  []uint64
+	}{
+		{
+			name:      "from sequence",
+			from:      &ReplayFrom{Sequence: 2},
+			wantCount: 2,
+			wantSeqs:  []uint64{2, 3},
+		},
+		{
+			name:      "from time",
+			from:      &ReplayFrom{Time: time.Now().Add(-time.Hour)},
+			wantCount: 3,
+			wantSeqs:  []uint64{1, 2, 3},
+		},
+		{
+			name: "from future",
+			from: &ReplayFrom{Time: time.Now().Add(time.Hour)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dao := new(mockedAuditDAO)
+			dao.On("CreateAuditEvent", mock.Anything, mock.Anything).Return(nil)
+
+			consumer := NewConsumer(&log.Logger, js, NewRecorder(&log.Logger, dao))
+
+			count, err := consumer.Replay(t.Context(), tt.from)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCount, count)
+			assert.Equal(t, tt.wantSeqs, recordedSeqs(dao))
+		})
+	}
+}
+
+func TestParseReplayFrom(t *testing.T) {
+	from, err := ParseReplayFrom("42")
+	require.NoError(t, err)
+	assert.Equal(t, &ReplayFrom{Sequence: 42}, from)
+
+	from, err = ParseReplayFrom("2025-01-02T03:04:05Z")
+	require.NoError(t, err)
+	assert.Equal(t, &ReplayFrom{Time: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)}, from)
+
+	_, err = ParseReplayFrom("0")
+	assert.Error(t, err)
+
+	_, err = ParseReplayFrom("yesterday")
+	assert.Error(t, err)
+}
@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/database"
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/rs/zerolog"
+)
+
+// Recorder decodes notifications and stores them in the audit store.
+type Recorder struct {
+	logger *zerolog.Logger
+	dao    database.AuditDAO
+}
+
+func NewRecorder(logger *zerolog.Logger, dao database.AuditDAO) *Recorder {
+	return &Recorder{logger: logger, dao: dao}
+}
+
+// Record stores a message received from the stream. The stream sequence
+// makes recording idempotent, so messages can be redelivered or replayed.
+func (r *Recorder) Record(ctx context.Context, seq uint64, receivedAt time.Time, data []byte) error {
+	e, err := events.Decode(data)
+	if err != nil {
+		return fmt.Errorf("events.Decode: %w", err)
+	}
+
+	event := &model.AuditEvent{
+		StreamSeq:  seq,
+		EventID:    e.ID,
+		OccurredAt: e.Time,
+		Actor:      e.Actor,
+		ProductID:  e.ProductID,
+		ReviewID:   e.ReviewID,
+		Action:     e.Action,
+		ReceivedAt: receivedAt.UTC(),
+		Payload:    string(data),
+	}
+
+	if err := r.dao.CreateAuditEvent(ctx, event); err != nil {
+		return fmt.Errorf("dao.CreateAuditEvent: %w", err)
+	}
+
+	r.logger.Info().
+		Uint64("seq", seq).
+		Str("id", e.ID).
+		Int("product", e.ProductID).
+		Int("review", e.ReviewID).
+		Str("action", e.Action).
+		Msg("event recorded")
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRecorder_Record(t *testing.T) {
+	occurredAt := time.Date(2025, 1, 2, 3, 4, 4, 0, time.UTC)
+	receivedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	data := []byte(`{"schema_version":1,"id":"e1","time":"2025-01-02T03:04:04Z","actor":"support",` +
+		`"action":"create","product_id":1,"review_id":2}`)
+
+	dao := new(mockedAuditDAO)
+	dao.On("CreateAuditEvent", mock.Anything, &model.AuditEvent{
+		StreamSeq:  42,
+		EventID:    "e1",
+		OccurredAt: occurredAt,
+		Actor:      "support",
+		ProductID:  1,
+		ReviewID:   2,
+		Action:     "create",
+		ReceivedAt: receivedAt,
+		Payload:    string(data),
+	}).Return(nil).Once()
+
+	err := NewRecorder(&log.Logger, dao).Record(t.Context(), 42, receivedAt, data)
+	assert.NoError(t, err)
+	dao.AssertExpectations(t)
+}
+
+func TestRecorder_RecordInvalid(t *testing.T) {
+	dao := new(mockedAuditDAO)
+
+	for _, data := range []string{`not json`, `{"product":1,"review":2,"action":"create"}`} {
+		err := NewRecorder(&log.Logger, dao).Record(t.Context(), 1, time.Now(), []byte(data))
+		assert.ErrorIs(t, err, events.ErrInvalidEvent)
+	}
+
+	dao.AssertNotCalled(t, "CreateAuditEvent", mock.Anything, mock.Anything)
+}
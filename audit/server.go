@@ -0,0 +1,172 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lameaux/golang-product-reviews/database"
+	"github.com/lameaux/golang-product-reviews/dto"
+	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/rs/zerolog"
+)
+
+const maxLimit = 1000
+
+type Server struct {
+	srv    *http.Server
+	port   int
+	logger *zerolog.Logger
+	dao    database.AuditDAO
+}
+
+func NewServer(
+	port int,
+	logger *zerolog.Logger,
+	dao database.AuditDAO,
+) *Server {
+	return &Server{
+		port:   port,
+		logger: logger,
+		dao:    dao,
+		srv: &http.Server{
+			Addr:         fmt.Sprintf(":%d", port),
+			WriteTimeout: time.Second * 15,
+			ReadTimeout:  time.Second * 15,
+			IdleTimeout:  time.Second * 60,
+		},
+	}
+}
+
+func (s *Server) Serve() error {
+	s.srv.Handler = s.CreateRouter()
+
+	s.logger.Info().Int("port", s.port).Msg("starting http server")
+
+	err := s.srv.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
+		s.logger.Error().Int("port", s.port).Err(err).Msg("http server failed to start")
+		return err
+	}
+
+	return nil
+}
+
+func (s *Server) CreateRouter() *mux.Router {
+	r := mux.NewRouter()
+	r.HandleFunc("/health", s.handleHealth()).Methods("GET")
+	r.HandleFunc("/events", s.handleListEvents()).Methods("GET")
+
+	return r
+}
+
+func (s *Server) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	if err := s.srv.Shutdown(ctx); err != nil {
+		s.logger.Error().Err(err).Msg("HTTP server shutdown error")
+	}
+}
+
+func (s *Server) handleHealth() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (s *Server) handleListEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseFilter(r)
+		if err != nil {
+			http.Error(w, "handleListEvents - "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		events, err := s.dao.ListAuditEvents(r.Context(), filter)
+		if err != nil {
+			http.Error(w, "handleListEvents - ListAuditEvents: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result := make([]*dto.AuditEvent, 0, len(events))
+		for _, event := range events {
+			result = append(result, convertEvent(event))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			s.logger.Error().Err(err).Msg("encode response failed")
+		}
+	}
+}
+
+func parseFilter(r *http.Request) (*database.AuditFilter, error) {
+	var (
+		filter = &database.AuditFilter{Action: r.URL.Query().Get("action")}
+		err    error
+	)
+
+	if filter.ProductID, err = getIntQuery(r, "product", 0); err != nil {
+		return nil, fmt.Errorf("invalid product: %w", err)
+	}
+	if filter.ReviewID, err = getIntQuery(r, "review", 0); err != nil {
+		return nil, fmt.Errorf("invalid review: %w", err)
+	}
+	if filter.From, err = getTimeQuery(r, "from"); err != nil {
+		return nil, fmt.Errorf("invalid from: %w", err)
+	}
+	if filter.To, err = getTimeQuery(r, "to"); err != nil {
+		return nil, fmt.Errorf("invalid to: %w", err)
+	}
+	if filter.Offset, err = getIntQuery(r, "offset", 0); err != nil {
+		return nil, fmt.Errorf("invalid offset: %w", err)
+	}
+	if filter.Limit, err = getIntQuery(r, "limit", 100); err != nil {
+		return nil, fmt.Errorf("invalid limit: %w", err)
+	}
+
+	if filter.Offset < 0 || filter.Limit <= 0 || filter.Limit > maxLimit {
+		return nil, fmt.Errorf("invalid pagination: offset=%d limit=%d", filter.Offset, filter.Limit)
+	}
+
+	return filter, nil
+}
+
+func getIntQuery(r *http.Request, key string, def int) (int, error) {
+	val := r.URL.Query().Get(key)
+	if val == "" {
+		return def, nil
+	}
+
+	return strconv.Atoi(val)
+}
+
+func getTimeQuery(r *http.Request, key string) (time.Time, error) {
+	val := r.URL.Query().Get(key)
+	if val == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, val)
+}
+
+func convertEvent(event *model.AuditEvent) *dto.AuditEvent {
+	return &dto.AuditEvent{
+		ID:         event.ID,
+		EventID:    event.EventID,
+		OccurredAt: event.OccurredAt,
+		Actor:      event.Actor,
+		ProductID:  event.ProductID,
+		ReviewID:   event.ReviewID,
+		Action:     event.Action,
+		ReceivedAt: event.ReceivedAt,
+		Payload:    json.RawMessage(event.Payload),
+	}
+}
@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/database"
+	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockedAuditDAO struct {
+	mock.Mock
+}
+
+var _ database.AuditDAO = (*mockedAuditDAO)(nil)
+
+func (m *mockedAuditDAO) CreateAuditEvent(ctx context.Context, event *model.AuditEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *mockedAuditDAO) ListAuditEvents(ctx context.Context, filter *database.AuditFilter) ([]*model.AuditEvent, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*model.AuditEvent), args.Error(1)
+}
+
+func TestHandleListEvents(t *testing.T) {
+	receivedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantFilter *database.AuditFilter
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "invalid product",
+			query:      "product=abc",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "handleListEvents - invalid product",
+		},
+		{
+			name:       "invalid from",
+			query:      "from=yesterday",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "handleListEvents - invalid from",
+		},
+		{
+			name:       "limit too large",
+			query:      "limit=5000",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "handleListEvents - invalid pagination",
+		},
+		{
+			name:  "valid",
+			query: "product=1&action=update&from=2025-01-01T00:00:00Z&to=2025-02-01T00:00:00Z&offset=10&limit=5",
+			wantFilter: &database.AuditFilter{
+				ProductID: 1,
+				Action:    "update",
+				From:      time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+				To:        time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+				Offset:    10,
+				Limit:     5,
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `[{"id":7,"event_id":"e1","occurred_at":"2025-01-02T03:04:04Z","actor":"support","product_id":1,"review_id":2,"action":"update","received_at":"2025-01-02T03:04:05Z","payload":{"product":1,"review":2,"action":"update"}}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dao := new(mockedAuditDAO)
+			if tt.wantFilter != nil {
+				dao.On("ListAuditEvents", mock.Anything, tt.wantFilter).Return([]*model.AuditEvent{
+					{
+						ID:         7,
+						EventID:    "e1",
+						OccurredAt: receivedAt.Add(-time.Second),
+						Actor:      "support",
+						ProductID:  1,
+						ReviewID:   2,
+						Action:     "update",
+						ReceivedAt: receivedAt,
+						Payload:    `{"product":1,"review":2,"action":"update"}`,
+					},
+				}, nil)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/events?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			NewServer(0, &log.Logger, dao).CreateRouter().ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			require.Contains(t, strings.TrimSpace(rec.Body.String()), tt.wantBody)
+			dao.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+// Kinds of cached keys.
+const (
+	KindGeneration = "generation"
+	KindValue      = "value"
+	KindTombstone  = "tombstone"
+	// KindFence keys hold the last fencing token, they aren't listed as entries.
+	KindFence = "fence"
+)
+
+// Entry is a cached key with its expiry.
+type Entry struct {
+	Key  string
+	Kind string
+	// TTL until the key is dropped, negative for keys without expiry.
+	TTL time.Duration
+	// SoftTTL until a value is stale, only set for KindValue.
+	SoftTTL time.Duration
+}
+
+// Admin inspects and flushes cached entries.
+type Admin interface {
+	// ProductEntries lists the keys of the product, of all generations.
+	ProductEntries(ctx context.Context, productID model.ID) (Generation, []Entry, error)
+	// FlushProduct invalidates the product and deletes its entries.
+	FlushProduct(ctx context.Context, productID model.ID) (int, error)
+	// FlushAll invalidates all products and listing pages and deletes all entries.
+	FlushAll(ctx context.Context) (int, error)
+}
+
+var _ Admin = (*RedisAdmin)(nil)
+
+const scanCount = 1000
+
+// RedisAdmin works on the keys of RedisCache. Invalidations go through dao,
+// the whole cache stack, so local tiers of all replicas drop their entries too.
+// Generation keys are incremented rather than deleted: a generation that went
+// back to 0 would make values loaded before the flush readable again.
+type RedisAdmin struct {
+	logger *zerolog.Logger
+	client *redis.Client
+	dao    DAO
+	now    func() time.Time
+}
+
+func NewRedisAdmin(logger *zerolog.Logger, client *redis.Client, dao DAO) *RedisAdmin {
+	return &RedisAdmin{logger: logger, client: client, dao: dao, now: time.Now}
+}
+
+func (a *RedisAdmin) ProductEntries(ctx context.Context, productID model.ID) (Generation, []Entry, error) {
+	gen, err := a.dao.ProductGeneration(ctx, productID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("ProductGeneration: %w", err)
+	}
+
+	var entries []Entry
+	err = a.scan(ctx, fmt.Sprintf("%s:%d:*", prefix, productID), func(keys []string) error {
+		batch, err := a.entries(ctx, keys)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, batch...)
+		return nil
+	})
+	if err != nil {
+		return 0, nil, fmt.Errorf("scan: %w", err)
+	}
+
+	return gen, entries, nil
+}
+
+func (a *RedisAdmin) FlushProduct(ctx context.Context, productID model.ID) (int, error) {
+	a.dao.InvalidateProduct(ctx, productID)
+
+	deleted := 0
+	err := a.scan(ctx, fmt.Sprintf("%s:%d:*", prefix, productID), func(keys []string) error {
+		n, err := a.unlink(ctx, keys)
+		deleted += n
+		return err
+	})
+	if err != nil {
+		return deleted, fmt.Errorf("scan: %w", err)
+	}
+
+	a.logger.Info().Int("product", productID).Int("deleted", deleted).Msg("cache flushed")
+
+	return deleted, nil
+}
+
+func (a *RedisAdmin) FlushAll(ctx context.Context) (int, error) {
+	a.dao.InvalidateProductListing(ctx)
+
+	deleted := 0
+	err := a.scan(ctx, prefix+":*", func(keys []string) error {
+		for _, key := range keys {
+			if productID, ok := generationKeyProduct(key); ok {
+				a.dao.InvalidateProduct(ctx, productID)
+			}
+		}
+
+		n, err := a.unlink(ctx, keys)
+		deleted += n
+		return err
+	})
+	if err != nil {
+		return deleted, fmt.Errorf("scan: %w", err)
+	}
+
+	a.logger.Info().Int("deleted", deleted).Msg("cache flushed")
+
+	return deleted, nil
+}
+
+func (a *RedisAdmin) scan(ctx context.Context, pattern string, handle func(keys []string) error) error {
+	iter := a.client.Scan(ctx, 0, pattern, scanCount).Iterator()
+
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == scanCount {
+			if err := handle(keys); err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return handle(keys)
+}
+
+// unlink deletes cached values and tombstones, generation and fence keys and
+// other keys sharing the prefix, like locks, are kept. A flushed fence would
+// let a holder that lost its lock write again.
+func (a *RedisAdmin) unlink(ctx context.Context, keys []string) (int, error) {
+	var values []string
+	for _, key := range keys {
+		if kind, ok := keyKind(key); ok && kind == KindValue {
+			values = append(values, key)
+		}
+	}
+
+	if len(values) == 0 {
+		return 0, nil
+	}
+
+	n, err := a.client.Unlink(ctx, values...).Result()
+	if err != nil {
+		return 0, fmt.Errorf("unlink: %w", err)
+	}
+
+	return int(n), nil
+}
+
+func (a *RedisAdmin) entries(ctx context.Context, keys []string) ([]Entry, error) {
+	pipe := a.client.Pipeline()
+	ttls := make([]*redis.DurationCmd, len(keys))
+	values := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		ttls[i] = pipe.PTTL(ctx, key)
+		values[i] = pipe.Get(ctx, key)
+	}
+	// keys may expire between the scan and the pipeline
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("pipeline: %w", err)
+	}
+
+	entries := make([]Entry, 0, len(keys))
+	for i, key := range keys {
+		kind, ok := keyKind(key)
+		if !ok || kind == KindFence {
+			continue
+		}
+
+		bytes, err := values[i].Bytes()
+		if err == redis.Nil {
+			continue
+		}
+
+		entry := Entry{Key: key, Kind: kind, TTL: ttls[i].Val()}
+		if kind != KindGeneration {
+			env, err := parseEnvelope(bytes)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %w", key, err)
+			}
+
+			if env.Missing {
+				entry.Kind = KindTombstone
+			} else {
+				entry.SoftTTL = time.UnixMilli(env.SoftExpiry).Sub(a.now())
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// keyKind tells cache keys, products:<id>:... and products:listing:...,
+// apart from other keys under the prefix.
+func keyKind(key string) (string, bool) {
+	parts := strings.Split(key, ":")
+	if len(parts) < 3 || parts[0] != prefix {
+		return "", false
+	}
+
+	if parts[1] != "listing" {
+		if _, err := strconv.Atoi(parts[1]); err != nil {
+			return "", false
+		}
+	}
+
+	if len(parts) == 3 && parts[2] == "generation" {
+		return KindGeneration, true
+	}
+
+	if len(parts) == 3 && parts[2] == "fence" {
+		return KindFence, true
+	}
+
+	return KindValue, true
+}
+
+// generationKeyProduct returns the product of a product generation key.
+func generationKeyProduct(key string) (model.ID, bool) {
+	id, ok := strings.CutPrefix(key, prefix+":")
+	if !ok {
+		return 0, false
+	}
+
+	id, ok = strings.CutSuffix(id, ":generation")
+	if !ok {
+		return 0, false
+	}
+
+	productID, err := strconv.Atoi(id)
+	if err != nil {
+		return 0, false
+	}
+
+	return productID, true
+}
@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyKind(t *testing.T) {
+	tests := []struct {
+		key      string
+		wantKind string
+		wantOK   bool
+	}{
+		{key: "products:1:generation", wantKind: KindGeneration, wantOK: true},
+		{key: "products:1:3:product", wantKind: KindValue, wantOK: true},
+		{key: "products:1:3:reviews:0:100", wantKind: KindValue, wantOK: true},
+		{key: "products:listing:generation", wantKind: KindGeneration, wantOK: true},
+		{key: "products:listing:3:0:100", wantKind: KindValue, wantOK: true},
+		{key: "products:1:fence", wantKind: KindFence, wantOK: true},
+		{key: "products:listing:fence", wantKind: KindFence, wantOK: true},
+		{key: "products:locks:1", wantOK: false},
+		{key: "reviews", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			kind, ok := keyKind(tt.key)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantKind, kind)
+		})
+	}
+}
+
+func TestGenerationKeyProduct(t *testing.T) {
+	productID, ok := generationKeyProduct(productGenerationKey(42))
+	assert.True(t, ok)
+	assert.Equal(t, 42, productID)
+
+	_, ok = generationKeyProduct(listingGenerationKey)
+	assert.False(t, ok)
+
+	_, ok = generationKeyProduct(productKey(42, 1, "product"))
+	assert.False(t, ok)
+}
@@ -3,21 +3,57 @@ package cache
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/lameaux/golang-product-reviews/model"
 )
 
 var NotFound = errors.New("not found")
 
+// Stale is returned together with a value that is due for a refresh.
+// The value can still be served.
+var Stale = errors.New("stale")
+
+// Generation is the version of cached entries, it changes on every invalidation.
+// Callers read it before loading from the database and pass it to Set*
+// in Load, so a value loaded before a concurrent write is stored under an old
+// generation and never served.
+type Generation int64
+
+// Load describes how a value was loaded from the database.
+type Load struct {
+	// Generation read before loading.
+	Generation Generation
+	// Duration of the load, slow loads are refreshed earlier.
+	Duration time.Duration
+	// Fence is the fencing token of the lock held while loading, 0 if the lock
+	// can't be lost. Writes with an older token than the last one are dropped.
+	Fence uint64
+}
+
+// DAO caches entities. GetProduct and GetProductReview return a nil value
+// without error for entities known to be missing: SetProduct and
+// SetProductReview with a nil value store a tombstone with a short TTL.
 type DAO interface {
 	InvalidateProduct(ctx context.Context, id model.ID)
+	ProductGeneration(ctx context.Context, productID model.ID) (Generation, error)
+
+	// InvalidateProductListing drops all cached listing pages.
+	InvalidateProductListing(ctx context.Context)
+	ListingGeneration(ctx context.Context) (Generation, error)
+
+	GetProduct(ctx context.Context, productID model.ID) (*model.Product, error)
+	SetProduct(ctx context.Context, productID model.ID, load Load, product *model.Product)
+
+	GetProducts(ctx context.Context, offset int, limit int) ([]*model.Product, error)
+	SetProducts(ctx context.Context, load Load, offset int, limit int, products []*model.Product)
 
 	GetProductRating(ctx context.Context, productID model.ID) (float32, error)
-	SetProductRating(ctx context.Context, productID model.ID, rating float32)
+	SetProductRating(ctx context.Context, productID model.ID, load Load, rating float32)
 
 	GetProductReview(ctx context.Context, productID model.ID, reviewID model.ID) (*model.Review, error)
-	SetProductReview(ctx context.Context, productID model.ID, reviewID model.ID, review *model.Review)
+	SetProductReview(ctx context.Context, productID model.ID, load Load, reviewID model.ID, review *model.Review)
 
 	GetProductReviews(ctx context.Context, productID model.ID, offset int, limit int) ([]*model.Review, error)
-	SetProductReviews(ctx context.Context, productID model.ID, offset int, limit int, reviews []*model.Review)
+	SetProductReviews(ctx context.Context, productID model.ID, load Load, offset int, limit int, reviews []*model.Review)
 }
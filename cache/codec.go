@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec serializes cached values. Its ID is stored with every entry,
+// so entries written with another codec stay readable after a switch.
+type Codec interface {
+	ID() byte
+	Name() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+const (
+	codecJSON     byte = 1
+	codecMsgPack  byte = 2
+	codecProtobuf byte = 3
+)
+
+var (
+	JSON     Codec = jsonCodec{}
+	MsgPack  Codec = msgPackCodec{}
+	Protobuf Codec = protobufCodec{}
+)
+
+var codecs = map[byte]Codec{
+	codecJSON:     JSON,
+	codecMsgPack:  MsgPack,
+	codecProtobuf: Protobuf,
+}
+
+// CodecByName returns the codec for json, msgpack or protobuf.
+func CodecByName(name string) (Codec, error) {
+	for _, codec := range codecs {
+		if codec.Name() == name {
+			return codec, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown codec %q", name)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ID() byte     { return codecJSON }
+func (jsonCodec) Name() string { return "json" }
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// msgPackCodec encodes structs as arrays, without field names.
+// Entries written before a model field is added or reordered fail to decode
+// and are treated as misses.
+type msgPackCodec struct{}
+
+func (msgPackCodec) ID() byte     { return codecMsgPack }
+func (msgPackCodec) Name() string { return "msgpack" }
+
+func (msgPackCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.UseArrayEncodedStructs(true)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (msgPackCodec) Unmarshal(data []byte, v any) error {
+	return msgpack.Unmarshal(data, v)
+}
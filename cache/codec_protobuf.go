@@ -0,0 +1,227 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/lameaux/golang-product-reviews/model"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// protobufCodec writes the protobuf wire format of the cached model types
+// without generated code. The schema is in cache.proto.
+type protobufCodec struct{}
+
+func (protobufCodec) ID() byte     { return codecProtobuf }
+func (protobufCodec) Name() string { return "protobuf" }
+
+// Field numbers of cache.proto.
+const (
+	fieldProductID          protowire.Number = 1
+	fieldProductName        protowire.Number = 2
+	fieldProductDescription protowire.Number = 3
+	fieldProductPrice       protowire.Number = 4
+
+	fieldReviewID        protowire.Number = 1
+	fieldReviewProductID protowire.Number = 2
+	fieldReviewFirstName protowire.Number = 3
+	fieldReviewLastName  protowire.Number = 4
+	fieldReviewReview    protowire.Number = 5
+	fieldReviewRating    protowire.Number = 6
+
+	// fieldItems holds repeated messages of Products, Reviews and the Rating value.
+	fieldItems protowire.Number = 1
+)
+
+func (protobufCodec) Marshal(v any) ([]byte, error) {
+	switch v := v.(type) {
+	case *model.Product:
+		return appendProduct(nil, v), nil
+	case []*model.Product:
+		var b []byte
+		for _, product := range v {
+			b = protowire.AppendTag(b, fieldItems, protowire.BytesType)
+			b = protowire.AppendBytes(b, appendProduct(nil, product))
+		}
+		return b, nil
+	case *model.Review:
+		return appendReview(nil, v), nil
+	case []*model.Review:
+		var b []byte
+		for _, review := range v {
+			b = protowire.AppendTag(b, fieldItems, protowire.BytesType)
+			b = protowire.AppendBytes(b, appendReview(nil, review))
+		}
+		return b, nil
+	case float32:
+		b := protowire.AppendTag(nil, fieldItems, protowire.Fixed32Type)
+		return protowire.AppendFixed32(b, math.Float32bits(v)), nil
+	default:
+		return nil, fmt.Errorf("protobuf: unsupported type %T", v)
+	}
+}
+
+func (protobufCodec) Unmarshal(data []byte, v any) error {
+	switch v := v.(type) {
+	case *model.Product:
+		return consumeProduct(data, v)
+	case *[]*model.Product:
+		*v = []*model.Product{}
+		return consumeFields(data, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+			if num != fieldItems || typ != protowire.BytesType {
+				return protowire.ConsumeFieldValue(num, typ, b), nil
+			}
+			msg, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			var product model.Product
+			if err := consumeProduct(msg, &product); err != nil {
+				return 0, err
+			}
+			*v = append(*v, &product)
+			return n, nil
+		})
+	case *model.Review:
+		return consumeReview(data, v)
+	case *[]*model.Review:
+		*v = []*model.Review{}
+		return consumeFields(data, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+			if num != fieldItems || typ != protowire.BytesType {
+				return protowire.ConsumeFieldValue(num, typ, b), nil
+			}
+			msg, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			var review model.Review
+			if err := consumeReview(msg, &review); err != nil {
+				return 0, err
+			}
+			*v = append(*v, &review)
+			return n, nil
+		})
+	case *float32:
+		return consumeFields(data, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+			if num != fieldItems || typ != protowire.Fixed32Type {
+				return protowire.ConsumeFieldValue(num, typ, b), nil
+			}
+			bits, n := protowire.ConsumeFixed32(b)
+			*v = math.Float32frombits(bits)
+			return n, nil
+		})
+	default:
+		return fmt.Errorf("protobuf: unsupported type %T", v)
+	}
+}
+
+func appendProduct(b []byte, p *model.Product) []byte {
+	b = appendVarint(b, fieldProductID, int64(p.ID))
+	b = appendString(b, fieldProductName, p.Name)
+	b = appendString(b, fieldProductDescription, p.Description)
+	b = appendVarint(b, fieldProductPrice, int64(p.Price))
+	return b
+}
+
+func appendReview(b []byte, r *model.Review) []byte {
+	b = appendVarint(b, fieldReviewID, int64(r.ID))
+	b = appendVarint(b, fieldReviewProductID, int64(r.ProductID))
+	b = appendString(b, fieldReviewFirstName, r.FirstName)
+	b = appendString(b, fieldReviewLastName, r.LastName)
+	b = appendString(b, fieldReviewReview, r.Review)
+	b = appendVarint(b, fieldReviewRating, int64(r.Rating))
+	return b
+}
+
+// appendVarint and appendString skip zero values like proto3.
+func appendVarint(b []byte, num protowire.Number, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func consumeProduct(data []byte, p *model.Product) error {
+	return consumeFields(data, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case fieldProductID:
+			return consumeInt(b, &p.ID)
+		case fieldProductName:
+			return consumeString(b, &p.Name)
+		case fieldProductDescription:
+			return consumeString(b, &p.Description)
+		case fieldProductPrice:
+			return consumeInt(b, &p.Price)
+		default:
+			return protowire.ConsumeFieldValue(num, typ, b), nil
+		}
+	})
+}
+
+func consumeReview(data []byte, r *model.Review) error {
+	return consumeFields(data, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case fieldReviewID:
+			return consumeInt(b, &r.ID)
+		case fieldReviewProductID:
+			return consumeInt(b, &r.ProductID)
+		case fieldReviewFirstName:
+			return consumeString(b, &r.FirstName)
+		case fieldReviewLastName:
+			return consumeString(b, &r.LastName)
+		case fieldReviewReview:
+			return consumeString(b, &r.Review)
+		case fieldReviewRating:
+			return consumeInt(b, &r.Rating)
+		default:
+			return protowire.ConsumeFieldValue(num, typ, b), nil
+		}
+	})
+}
+
+var errProtobuf = errors.New("protobuf: malformed message")
+
+// consumeFields calls field with the value bytes of every field,
+// field returns the length of the value or a negative protowire error code.
+func consumeFields(data []byte, field func(num protowire.Number, typ protowire.Type, b []byte) (int, error)) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return fmt.Errorf("%w: %w", errProtobuf, protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		n, err := field(num, typ, data)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			return fmt.Errorf("%w: %w", errProtobuf, protowire.ParseError(n))
+		}
+		data = data[n:]
+	}
+
+	return nil
+}
+
+func consumeInt(b []byte, v *int) (int, error) {
+	x, n := protowire.ConsumeVarint(b)
+	*v = int(x)
+	return n, nil
+}
+
+func consumeString(b []byte, v *string) (int, error) {
+	s, n := protowire.ConsumeString(b)
+	*v = s
+	return n, nil
+}
@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testReviews(n int) []*model.Review {
+	reviews := make([]*model.Review, 0, n)
+	for i := range n {
+		reviews = append(reviews, &model.Review{
+			ID:        i + 1,
+			ProductID: 42,
+			FirstName: "Sergej",
+			LastName:  "Sizov",
+			Review:    strings.Repeat("Great product, would buy again. ", i%5+1),
+			Rating:    i%5 + 1,
+		})
+	}
+	return reviews
+}
+
+func TestCodecs(t *testing.T) {
+	product := &model.Product{ID: 1, Name: "P1", Description: "P1 desc", Price: 100}
+	reviews := testReviews(3)
+
+	for _, codec := range codecs {
+		t.Run(codec.Name(), func(t *testing.T) {
+			data, err := codec.Marshal(product)
+			require.NoError(t, err)
+			var gotProduct model.Product
+			require.NoError(t, codec.Unmarshal(data, &gotProduct))
+			assert.Equal(t, product, &gotProduct)
+
+			data, err = codec.Marshal([]*model.Product{product})
+			require.NoError(t, err)
+			var gotProducts []*model.Product
+			require.NoError(t, codec.Unmarshal(data, &gotProducts))
+			assert.Equal(t, []*model.Product{product}, gotProducts)
+
+			data, err = codec.Marshal(reviews[0])
+			require.NoError(t, err)
+			var gotReview model.Review
+			require.NoError(t, codec.Unmarshal(data, &gotReview))
+			assert.Equal(t, reviews[0], &gotReview)
+
+			data, err = codec.Marshal(reviews)
+			require.NoError(t, err)
+			var gotReviews []*model.Review
+			require.NoError(t, codec.Unmarshal(data, &gotReviews))
+			assert.Equal(t, reviews, gotReviews)
+
+			data, err = codec.Marshal(float32(4.5))
+			require.NoError(t, err)
+			var gotRating float32
+			require.NoError(t, codec.Unmarshal(data, &gotRating))
+			assert.Equal(t, float32(4.5), gotRating)
+		})
+	}
+}
+
+func TestEntryFormat(t *testing.T) {
+	reviews := testReviews(20)
+
+	for _, codec := range codecs {
+		for compression := range compressionNames {
+			t.Run(codec.Name()+"/"+compression.String(), func(t *testing.T) {
+				f := entryFormat{codec: codec, compression: compression, compressAbove: 100}
+
+				data, err := f.encode(envelope{SoftExpiry: 1735787045000, Delta: 12}, reviews)
+				require.NoError(t, err)
+
+				env, err := parseEnvelope(data)
+				require.NoError(t, err)
+				assert.Equal(t, int64(1735787045000), env.SoftExpiry)
+				assert.Equal(t, int64(12), env.Delta)
+				assert.False(t, env.Missing)
+
+				var got []*model.Review
+				require.NoError(t, env.decode(&got))
+				assert.Equal(t, reviews, got)
+
+				data, err = f.encode(envelope{Missing: true}, nil)
+				require.NoError(t, err)
+				env, err = parseEnvelope(data)
+				require.NoError(t, err)
+				assert.True(t, env.Missing)
+			})
+		}
+	}
+}
+
+func TestParseEnvelope_Legacy(t *testing.T) {
+	env, err := parseEnvelope([]byte(`{"v":4.5,"s":1735787045000,"d":12}`))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1735787045000), env.SoftExpiry)
+	assert.Equal(t, int64(12), env.Delta)
+
+	var rating float32
+	require.NoError(t, env.decode(&rating))
+	assert.Equal(t, float32(4.5), rating)
+
+	env, err = parseEnvelope([]byte(`{"m":true,"s":0,"d":0}`))
+	require.NoError(t, err)
+	assert.True(t, env.Missing)
+}
+
+func TestParseEnvelope_Malformed(t *testing.T) {
+	for _, data := range [][]byte{nil, {9}, {entryVersion, 99, 0, 0, 0, 0}, {entryVersion, codecJSON, 0}} {
+		_, err := parseEnvelope(data)
+		assert.ErrorIs(t, err, errEntry)
+	}
+}
+
+// BenchmarkCodecs compares sizes and speed of a page of 100 reviews,
+// entry size is reported as bytes/entry.
+func BenchmarkCodecs(b *testing.B) {
+	reviews := testReviews(100)
+
+	for _, codec := range []Codec{JSON, MsgPack, Protobuf} {
+		for _, compression := range []Compression{CompressionNone, CompressionSnappy, CompressionZstd} {
+			f := entryFormat{codec: codec, compression: compression}
+			name := fmt.Sprintf("%s/%s", codec.Name(), compression)
+
+			data, err := f.encode(envelope{}, reviews)
+			require.NoError(b, err)
+
+			b.Run(name+"/encode", func(b *testing.B) {
+				for b.Loop() {
+					if _, err := f.encode(envelope{}, reviews); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(len(data)), "bytes/entry")
+			})
+
+			b.Run(name+"/decode", func(b *testing.B) {
+				for b.Loop() {
+					env, err := parseEnvelope(data)
+					if err != nil {
+						b.Fatal(err)
+					}
+					var got []*model.Review
+					if err := env.decode(&got); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(len(data)), "bytes/entry")
+			})
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"fmt"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression of entry payloads, stored in the entry header.
+type Compression byte
+
+const (
+	CompressionNone Compression = iota
+	CompressionZstd
+	CompressionSnappy
+)
+
+var compressionNames = map[Compression]string{
+	CompressionNone:   "none",
+	CompressionZstd:   "zstd",
+	CompressionSnappy: "snappy",
+}
+
+func (c Compression) String() string {
+	if name, ok := compressionNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("compression(%d)", byte(c))
+}
+
+// ParseCompression returns the compression for none, zstd or snappy.
+func ParseCompression(name string) (Compression, error) {
+	for c, n := range compressionNames {
+		if n == name {
+			return c, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown compression %q", name)
+}
+
+// EncodeAll and DecodeAll of a zstd encoder and decoder are safe for concurrent use.
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+func compress(c Compression, data []byte) ([]byte, error) {
+	switch c {
+	case CompressionNone:
+		return data, nil
+	case CompressionZstd:
+		return zstdEncoder.EncodeAll(data, nil), nil
+	case CompressionSnappy:
+		return s2.EncodeSnappy(nil, data), nil
+	default:
+		return nil, fmt.Errorf("unknown compression %d", byte(c))
+	}
+}
+
+func decompress(c Compression, data []byte) ([]byte, error) {
+	switch c {
+	case CompressionNone:
+		return data, nil
+	case CompressionZstd:
+		return zstdDecoder.DecodeAll(data, nil)
+	case CompressionSnappy:
+		// s2 decodes snappy blocks
+		return s2.Decode(nil, data)
+	default:
+		return nil, fmt.Errorf("unknown compression %d", byte(c))
+	}
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+// TTL of an entity type. After Soft a value is stale: it is still served,
+// but the reader refreshes it in the background. After Hard it is gone.
+type TTL struct {
+	Soft time.Duration
+	Hard time.Duration
+}
+
+func (t TTL) Validate() error {
+	if t.Soft <= 0 || t.Hard < t.Soft {
+		return fmt.Errorf("invalid ttl soft=%s hard=%s", t.Soft, t.Hard)
+	}
+
+	return nil
+}
+
+type Config struct {
+	Product TTL
+	Listing TTL
+	Rating  TTL
+	Review  TTL
+	Reviews TTL
+	// Missing is the TTL of tombstones for missing products and reviews.
+	Missing time.Duration
+	// Beta tunes early refresh (XFetch), values above 1 favor earlier refreshes.
+	Beta float64
+
+	// Codec and Compression of new entries, entries of other codecs stay readable.
+	Codec       Codec
+	Compression Compression
+	// CompressAbove is the size in bytes from which values are compressed.
+	CompressAbove int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Product: TTL{Soft: 10 * time.Minute, Hard: time.Hour},
+		Listing: TTL{Soft: time.Minute, Hard: 10 * time.Minute},
+		Rating:  TTL{Soft: 5 * time.Minute, Hard: time.Hour},
+		Review:  TTL{Soft: 10 * time.Minute, Hard: time.Hour},
+		Reviews: TTL{Soft: 5 * time.Minute, Hard: time.Hour},
+		Missing: 30 * time.Second,
+		Beta:    1,
+
+		Codec:         MsgPack,
+		Compression:   CompressionSnappy,
+		CompressAbove: 1024,
+	}
+}
+
+func (c Config) Validate() error {
+	for name, ttl := range map[string]TTL{
+		"product": c.Product,
+		"listing": c.Listing,
+		"rating":  c.Rating,
+		"review":  c.Review,
+		"reviews": c.Reviews,
+	} {
+		if err := ttl.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	if c.Missing <= 0 {
+		return fmt.Errorf("invalid missing ttl %s", c.Missing)
+	}
+
+	if c.Beta <= 0 {
+		return fmt.Errorf("invalid beta %v", c.Beta)
+	}
+
+	if c.Codec == nil {
+		return fmt.Errorf("missing codec")
+	}
+
+	if _, ok := compressionNames[c.Compression]; !ok {
+		return fmt.Errorf("invalid compression %s", c.Compression)
+	}
+
+	if c.CompressAbove < 0 {
+		return fmt.Errorf("invalid compression threshold %d", c.CompressAbove)
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// entryVersion is the first byte of every entry. Entries of version 1:
+//
+//	version | codec | compression | flags | soft expiry (varint) | delta (varint) | payload
+//
+// The payload is the value encoded with the codec, compressed if it was
+// larger than the threshold. Readers decode any codec and compression,
+// so they can be switched without a flush.
+const entryVersion byte = 1
+
+const flagMissing byte = 1 << 0
+
+// legacyEntryStart starts JSON envelopes written before entries were versioned.
+const legacyEntryStart = '{'
+
+var errEntry = errors.New("malformed cache entry")
+
+// envelope is a cached value with its refresh metadata.
+type envelope struct {
+	// SoftExpiry in unix milliseconds.
+	SoftExpiry int64
+	// Delta is the load duration in milliseconds.
+	Delta int64
+	// Missing marks a tombstone of an entity that does not exist.
+	Missing bool
+
+	codec   Codec
+	payload []byte
+}
+
+// decode unmarshals the value of the envelope.
+func (env envelope) decode(v any) error {
+	return env.codec.Unmarshal(env.payload, v)
+}
+
+// entryFormat writes entries with one codec and compression.
+type entryFormat struct {
+	codec       Codec
+	compression Compression
+	// compressAbove is the payload size in bytes from which payloads are compressed.
+	compressAbove int
+}
+
+func (f entryFormat) encode(env envelope, value any) ([]byte, error) {
+	var payload []byte
+	if !env.Missing {
+		var err error
+		if payload, err = f.codec.Marshal(value); err != nil {
+			return nil, fmt.Errorf("marshal: %w", err)
+		}
+	}
+
+	compression := CompressionNone
+	if len(payload) > f.compressAbove {
+		compression = f.compression
+	}
+
+	payload, err := compress(compression, payload)
+	if err != nil {
+		return nil, fmt.Errorf("compress: %w", err)
+	}
+
+	var flags byte
+	if env.Missing {
+		flags |= flagMissing
+	}
+
+	b := make([]byte, 0, 4+2*binary.MaxVarintLen64+len(payload))
+	b = append(b, entryVersion, f.codec.ID(), byte(compression), flags)
+	b = binary.AppendVarint(b, env.SoftExpiry)
+	b = binary.AppendVarint(b, env.Delta)
+
+	return append(b, payload...), nil
+}
+
+func parseEnvelope(data []byte) (envelope, error) {
+	if len(data) == 0 {
+		return envelope{}, errEntry
+	}
+
+	switch data[0] {
+	case entryVersion:
+	case legacyEntryStart:
+		return parseLegacyEnvelope(data)
+	default:
+		return envelope{}, fmt.Errorf("%w: unknown version %d", errEntry, data[0])
+	}
+
+	if len(data) < 4 {
+		return envelope{}, errEntry
+	}
+
+	codec, ok := codecs[data[1]]
+	if !ok {
+		return envelope{}, fmt.Errorf("%w: unknown codec %d", errEntry, data[1])
+	}
+	compression := Compression(data[2])
+	env := envelope{Missing: data[3]&flagMissing != 0, codec: codec}
+
+	rest := data[4:]
+	var n int
+	if env.SoftExpiry, n = binary.Varint(rest); n <= 0 {
+		return envelope{}, errEntry
+	}
+	rest = rest[n:]
+	if env.Delta, n = binary.Varint(rest); n <= 0 {
+		return envelope{}, errEntry
+	}
+	rest = rest[n:]
+
+	payload, err := decompress(compression, rest)
+	if err != nil {
+		return envelope{}, fmt.Errorf("%w: %w", errEntry, err)
+	}
+	env.payload = payload
+
+	return env, nil
+}
+
+// parseLegacyEnvelope reads a JSON envelope, they expire with their TTL.
+func parseLegacyEnvelope(data []byte) (envelope, error) {
+	var legacy struct {
+		Value      json.RawMessage `json:"v"`
+		SoftExpiry int64           `json:"s"`
+		Delta      int64           `json:"d"`
+		Missing    bool            `json:"m,omitempty"`
+	}
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return envelope{}, fmt.Errorf("%w: %w", errEntry, err)
+	}
+
+	return envelope{
+		SoftExpiry: legacy.SoftExpiry,
+		Delta:      legacy.Delta,
+		Missing:    legacy.Missing,
+		codec:      JSON,
+		payload:    legacy.Value,
+	}, nil
+}
@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+// InvalidationBus broadcasts product invalidations to all API replicas.
+type InvalidationBus interface {
+	Publish(ctx context.Context, productID model.ID) error
+	// Subscribe calls handle for every invalidation until ctx is done.
+	Subscribe(ctx context.Context, handle func(productID model.ID)) error
+}
+
+var _ InvalidationBus = (*RedisInvalidationBus)(nil)
+
+const DefaultInvalidationChannel = "products:invalidations"
+
+// RedisInvalidationBus uses Redis pub/sub. Messages are not persisted,
+// a replica that misses one serves stale entries until the local TTL expires.
+type RedisInvalidationBus struct {
+	logger  *zerolog.Logger
+	client  *redis.Client
+	channel string
+}
+
+func NewRedisInvalidationBus(logger *zerolog.Logger, client *redis.Client, channel string) *RedisInvalidationBus {
+	return &RedisInvalidationBus{logger: logger, client: client, channel: channel}
+}
+
+func (b *RedisInvalidationBus) Publish(ctx context.Context, productID model.ID) error {
+	if err := b.client.Publish(ctx, b.channel, productID).Err(); err != nil {
+		return fmt.Errorf("publish: %w", err)
+	}
+
+	return nil
+}
+
+func (b *RedisInvalidationBus) Subscribe(ctx context.Context, handle func(productID model.ID)) error {
+	sub := b.client.Subscribe(ctx, b.channel)
+	defer sub.Close()
+
+	if _, err := sub.Receive(ctx); err != nil {
+		return fmt.Errorf("subscribe: %w", err)
+	}
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+
+			productID, err := strconv.Atoi(msg.Payload)
+			if err != nil {
+				b.logger.Warn().Str("payload", msg.Payload).Msg("invalid invalidation message")
+				continue
+			}
+
+			handle(productID)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/rs/zerolog"
+)
+
+var _ DAO = (*LocalCache)(nil)
+
+const (
+	DefaultLocalSize = 10_000
+	DefaultLocalTTL  = 5 * time.Second
+)
+
+// listingID keeps listing pages in the LRU and on the bus, product IDs start at 1.
+const listingID model.ID = 0
+
+// LocalCache is an in-process LRU in front of another cache, usually RedisCache.
+// Entries live for a short TTL. Invalidations are broadcast over the bus,
+// so every replica drops its local entries of the product.
+//
+// Local entries are only filled on reads from the next cache. Set* may carry
+// an outdated generation, those values are left to the next cache to discard.
+type LocalCache struct {
+	logger *zerolog.Logger
+	next   DAO
+	bus    InvalidationBus
+	lru    *lru
+}
+
+func NewLocal(logger *zerolog.Logger, next DAO, bus InvalidationBus, size int, ttl time.Duration) *LocalCache {
+	return &LocalCache{logger: logger, next: next, bus: bus, lru: newLRU(size, ttl)}
+}
+
+// Run drops local entries on invalidations from other replicas until ctx is done.
+func (c *LocalCache) Run(ctx context.Context) error {
+	return c.bus.Subscribe(ctx, func(productID model.ID) {
+		c.lru.removeProduct(productID)
+		c.logger.Debug().Int("product", productID).Msg("LocalCache invalidated")
+	})
+}
+
+func (c *LocalCache) InvalidateProduct(ctx context.Context, productID model.ID) {
+	c.lru.removeProduct(productID)
+	c.next.InvalidateProduct(ctx, productID)
+
+	if err := c.bus.Publish(ctx, productID); err != nil {
+		c.logger.Warn().Err(err).Int("product", productID).Msg("InvalidateProduct broadcast failed")
+	}
+}
+
+func (c *LocalCache) ProductGeneration(ctx context.Context, productID model.ID) (Generation, error) {
+	return c.next.ProductGeneration(ctx, productID)
+}
+
+func (c *LocalCache) InvalidateProductListing(ctx context.Context) {
+	c.lru.removeProduct(listingID)
+	c.next.InvalidateProductListing(ctx)
+
+	if err := c.bus.Publish(ctx, listingID); err != nil {
+		c.logger.Warn().Err(err).Msg("InvalidateProductListing broadcast failed")
+	}
+}
+
+func (c *LocalCache) ListingGeneration(ctx context.Context) (Generation, error) {
+	return c.next.ListingGeneration(ctx)
+}
+
+func (c *LocalCache) GetProduct(ctx context.Context, productID model.ID) (*model.Product, error) {
+	return getLocal(c, productID, "product", func() (*model.Product, error) {
+		return c.next.GetProduct(ctx, productID)
+	})
+}
+
+func (c *LocalCache) SetProduct(ctx context.Context, productID model.ID, load Load, product *model.Product) {
+	c.next.SetProduct(ctx, productID, load, product)
+}
+
+func (c *LocalCache) GetProducts(ctx context.Context, offset int, limit int) ([]*model.Product, error) {
+	return getLocal(c, listingID, fmt.Sprintf("listing:%d:%d", offset, limit), func() ([]*model.Product, error) {
+		return c.next.GetProducts(ctx, offset, limit)
+	})
+}
+
+func (c *LocalCache) SetProducts(ctx context.Context, load Load, offset int, limit int, products []*model.Product) {
+	c.next.SetProducts(ctx, load, offset, limit, products)
+}
+
+func (c *LocalCache) GetProductRating(ctx context.Context, productID model.ID) (float32, error) {
+	return getLocal(c, productID, "rating", func() (float32, error) {
+		return c.next.GetProductRating(ctx, productID)
+	})
+}
+
+func (c *LocalCache) SetProductRating(ctx context.Context, productID model.ID, load Load, rating float32) {
+	c.next.SetProductRating(ctx, productID, load, rating)
+}
+
+func (c *LocalCache) GetProductReview(ctx context.Context, productID model.ID, reviewID model.ID) (*model.Review, error) {
+	return getLocal(c, productID, fmt.Sprintf("review:%d", reviewID), func() (*model.Review, error) {
+		return c.next.GetProductReview(ctx, productID, reviewID)
+	})
+}
+
+func (c *LocalCache) SetProductReview(ctx context.Context, productID model.ID, load Load, reviewID model.ID, review *model.Review) {
+	c.next.SetProductReview(ctx, productID, load, reviewID, review)
+}
+
+func (c *LocalCache) GetProductReviews(ctx context.Context, productID model.ID, offset int, limit int) ([]*model.Review, error) {
+	return getLocal(c, productID, fmt.Sprintf("reviews:%d:%d", offset, limit), func() ([]*model.Review, error) {
+		return c.next.GetProductReviews(ctx, productID, offset, limit)
+	})
+}
+
+func (c *LocalCache) SetProductReviews(ctx context.Context, productID model.ID, load Load, offset int, limit int, reviews []*model.Review) {
+	c.next.SetProductReviews(ctx, productID, load, offset, limit, reviews)
+}
+
+// getLocal reads from the LRU and falls back to load, a loaded value is kept
+// unless an invalidation happened in the meantime. Stale values are not kept,
+// so the reader refreshes them.
+func getLocal[T any](c *LocalCache, productID model.ID, key string, load func() (T, error)) (T, error) {
+	if value, ok := c.lru.get(productID, key); ok {
+		return value.(T), nil
+	}
+
+	epoch := c.lru.currentEpoch()
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+
+	c.lru.setAt(epoch, productID, key, value)
+
+	return value, nil
+}
@@ -0,0 +1,271 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockedCache struct {
+	mock.Mock
+}
+
+var _ DAO = (*mockedCache)(nil)
+
+func (m *mockedCache) InvalidateProduct(ctx context.Context, productID model.ID) {
+	m.Called(ctx, productID)
+}
+
+func (m *mockedCache) InvalidateProductListing(ctx context.Context) {
+	m.Called(ctx)
+}
+
+func (m *mockedCache) ProductGeneration(ctx context.Context, productID model.ID) (Generation, error) {
+	args := m.Called(ctx, productID)
+	return args.Get(0).(Generation), args.Error(1)
+}
+
+func (m *mockedCache) ListingGeneration(ctx context.Context) (Generation, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(Generation), args.Error(1)
+}
+
+func (m *mockedCache) GetProduct(ctx context.Context, productID model.ID) (*model.Product, error) {
+	args := m.Called(ctx, productID)
+	return args.Get(0).(*model.Product), args.Error(1)
+}
+
+func (m *mockedCache) SetProduct(ctx context.Context, productID model.ID, load Load, product *model.Product) {
+	m.Called(ctx, productID, load, product)
+}
+
+func (m *mockedCache) GetProducts(ctx context.Context, offset int, limit int) ([]*model.Product, error) {
+	args := m.Called(ctx, offset, limit)
+	return args.Get(0).([]*model.Product), args.Error(1)
+}
+
+func (m *mockedCache) SetProducts(ctx context.Context, load Load, offset int, limit int, products []*model.Product) {
+	m.Called(ctx, load, offset, limit, products)
+}
+
+func (m *mockedCache) GetProductRating(ctx context.Context, productID model.ID) (float32, error) {
+	args := m.Called(ctx, productID)
+	return args.Get(0).(float32), args.Error(1)
+}
+
+func (m *mockedCache) SetProductRating(ctx context.Context, productID model.ID, load Load, rating float32) {
+	m.Called(ctx, productID, load, rating)
+}
+
+func (m *mockedCache) GetProductReview(ctx context.Context, productID model.ID, reviewID model.ID) (*model.Review, error) {
+	args := m.Called(ctx, productID, reviewID)
+	return args.Get(0).(*model.Review), args.Error(1)
+}
+
+func (m *mockedCache) SetProductReview(ctx context.Context, productID model.ID, load Load, reviewID model.ID, review *model.Review) {
+	m.Called(ctx, productID, load, reviewID, review)
+}
+
+func (m *mockedCache) GetProductReviews(ctx context.Context, productID model.ID, offset int, limit int) ([]*model.Review, error) {
+	args := m.Called(ctx, productID, offset, limit)
+	return args.Get(0).([]*model.Review), args.Error(1)
+}
+
+func (m *mockedCache) SetProductReviews(ctx context.Context, productID model.ID, load Load, offset int, limit int, reviews []*model.Review) {
+	m.Called(ctx, productID, load, offset, limit, reviews)
+}
+
+// memoryBus delivers invalidations to all subscribers in the process.
+type memoryBus struct {
+	mu       sync.Mutex
+	handlers []func(productID model.ID)
+}
+
+func (b *memoryBus) Publish(_ context.Context, productID model.ID) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, handle := range b.handlers {
+		handle(productID)
+	}
+	return nil
+}
+
+func (b *memoryBus) Subscribe(ctx context.Context, handle func(productID model.ID)) error {
+	b.mu.Lock()
+	b.handlers = append(b.handlers, handle)
+	b.mu.Unlock()
+
+	<-ctx.Done()
+	return nil
+}
+
+func (b *memoryBus) subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.handlers)
+}
+
+func TestLocalCache_GetProductRating(t *testing.T) {
+	next := new(mockedCache)
+	next.On("GetProductRating", mock.Anything, 1).Return(float32(0), NotFound).Once()
+	next.On("GetProductRating", mock.Anything, 1).Return(float32(4.5), nil).Once()
+
+	c := NewLocal(&log.Logger, next, &memoryBus{}, 10, time.Minute)
+
+	_, err := c.GetProductRating(t.Context(), 1)
+	assert.ErrorIs(t, err, NotFound)
+
+	for range 3 {
+		rating, err := c.GetProductRating(t.Context(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, float32(4.5), rating)
+	}
+
+	next.AssertExpectations(t)
+}
+
+func TestLocalCache_Expiry(t *testing.T) {
+	next := new(mockedCache)
+	next.On("GetProductRating", mock.Anything, 1).Return(float32(4.5), nil).Twice()
+
+	c := NewLocal(&log.Logger, next, &memoryBus{}, 10, time.Minute)
+
+	now := time.Now()
+	c.lru.now = func() time.Time { return now }
+
+	_, err := c.GetProductRating(t.Context(), 1)
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Minute)
+	_, err = c.GetProductRating(t.Context(), 1)
+	require.NoError(t, err)
+
+	next.AssertExpectations(t)
+}
+
+func TestLocalCache_InvalidateProduct(t *testing.T) {
+	reviews := []*model.Review{{ID: 1, ProductID: 1}}
+
+	next := new(mockedCache)
+	next.On("GetProductReviews", mock.Anything, 1, 0, 10).Return(reviews, nil).Twice()
+	next.On("GetProductRating", mock.Anything, 2).Return(float32(3), nil).Once()
+	next.On("InvalidateProduct", mock.Anything, 1).Once()
+	next.On("GetProductReviews", mock.Anything, 1, 0, 10).Return(([]*model.Review)(nil), NotFound)
+
+	bus := &memoryBus{}
+	c := NewLocal(&log.Logger, next, bus, 10, time.Minute)
+	replica := NewLocal(&log.Logger, next, bus, 10, time.Minute)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go func() { _ = replica.Run(ctx) }()
+	require.Eventually(t, func() bool { return bus.subscribers() == 1 }, time.Second, time.Millisecond)
+
+	_, err := c.GetProductReviews(t.Context(), 1, 0, 10)
+	require.NoError(t, err)
+	_, err = replica.GetProductReviews(t.Context(), 1, 0, 10)
+	require.NoError(t, err)
+	_, err = replica.GetProductRating(t.Context(), 2)
+	require.NoError(t, err)
+
+	c.InvalidateProduct(t.Context(), 1)
+
+	_, err = c.GetProductReviews(t.Context(), 1, 0, 10)
+	assert.ErrorIs(t, err, NotFound)
+	_, err = replica.GetProductReviews(t.Context(), 1, 0, 10)
+	assert.ErrorIs(t, err, NotFound)
+
+	// other products are kept
+	assert.Equal(t, 1, replica.lru.len())
+}
+
+func TestLocalCache_Set(t *testing.T) {
+	next := new(mockedCache)
+	next.On("SetProductRating", mock.Anything, 1, Load{Generation: 3}, float32(4.5)).Once()
+	next.On("GetProductRating", mock.Anything, 1).Return(float32(4.5), nil).Once()
+
+	c := NewLocal(&log.Logger, next, &memoryBus{}, 10, time.Minute)
+
+	// the generation may be outdated, so the value is only kept in the next cache
+	c.SetProductRating(t.Context(), 1, Load{Generation: 3}, 4.5)
+	assert.Equal(t, 0, c.lru.len())
+
+	_, err := c.GetProductRating(t.Context(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, c.lru.len())
+
+	next.AssertExpectations(t)
+}
+
+func TestLRU_Eviction(t *testing.T) {
+	c := newLRU(2, time.Minute)
+
+	c.setAt(0, 1, "rating", 1)
+	c.setAt(0, 2, "rating", 2)
+	_, _ = c.get(1, "rating")
+	c.setAt(0, 3, "rating", 3)
+
+	_, ok := c.get(2, "rating")
+	assert.False(t, ok, "least recently used entry is evicted")
+
+	value, ok := c.get(1, "rating")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, 2, c.len())
+}
+
+func TestLRU_SetAtAfterInvalidation(t *testing.T) {
+	c := newLRU(10, time.Minute)
+
+	epoch := c.currentEpoch()
+	c.removeProduct(1)
+	c.setAt(epoch, 1, "rating", 1)
+
+	_, ok := c.get(1, "rating")
+	assert.False(t, ok, "a value loaded before the invalidation is not stored")
+}
+
+func TestLocalCache_InvalidateProductListing(t *testing.T) {
+	products := []*model.Product{{ID: 1, Name: "P1"}}
+
+	next := new(mockedCache)
+	next.On("GetProducts", mock.Anything, 0, 10).Return(products, nil).Once()
+	next.On("GetProduct", mock.Anything, 1).Return(products[0], nil).Once()
+	next.On("InvalidateProductListing", mock.Anything).Once()
+	next.On("GetProducts", mock.Anything, 0, 10).Return(([]*model.Product)(nil), NotFound)
+
+	bus := &memoryBus{}
+	c := NewLocal(&log.Logger, next, bus, 10, time.Minute)
+	replica := NewLocal(&log.Logger, next, bus, 10, time.Minute)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go func() { _ = replica.Run(ctx) }()
+	require.Eventually(t, func() bool { return bus.subscribers() == 1 }, time.Second, time.Millisecond)
+
+	_, err := replica.GetProducts(t.Context(), 0, 10)
+	require.NoError(t, err)
+	_, err = replica.GetProduct(t.Context(), 1)
+	require.NoError(t, err)
+
+	c.InvalidateProductListing(t.Context())
+
+	_, err = replica.GetProducts(t.Context(), 0, 10)
+	assert.ErrorIs(t, err, NotFound)
+
+	// product entities are not affected
+	product, err := replica.GetProduct(t.Context(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, products[0], product)
+
+	next.AssertExpectations(t)
+}
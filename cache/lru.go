@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/model"
+)
+
+type lruEntry struct {
+	productID model.ID
+	key       string
+	value     any
+	expires   time.Time
+}
+
+// lru is a size-bounded map with per-entry expiry, indexed by product
+// so all entries of a product can be dropped at once.
+type lru struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	now        func() time.Time
+	order      *list.List // front is most recently used
+	items      map[lruKey]*list.Element
+	byProduct  map[model.ID]map[lruKey]struct{}
+	epoch      uint64 // incremented on every invalidation
+}
+
+type lruKey struct {
+	productID model.ID
+	key       string
+}
+
+func newLRU(maxEntries int, ttl time.Duration) *lru {
+	return &lru{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		now:        time.Now,
+		order:      list.New(),
+		items:      make(map[lruKey]*list.Element),
+		byProduct:  make(map[model.ID]map[lruKey]struct{}),
+	}
+}
+
+func (c *lru) get(productID model.ID, key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[lruKey{productID, key}]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*lruEntry)
+	if c.now().After(entry.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+
+	return entry.value, true
+}
+
+// currentEpoch is read before loading a value for setAt.
+func (c *lru) currentEpoch() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.epoch
+}
+
+// setAt stores the value unless an invalidation happened since epoch was read,
+// the value may have been loaded before that invalidation.
+func (c *lru) setAt(epoch uint64, productID model.ID, key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.epoch == epoch {
+		c.store(productID, key, value)
+	}
+}
+
+func (c *lru) store(productID model.ID, key string, value any) {
+	k := lruKey{productID, key}
+	expires := c.now().Add(c.ttl)
+
+	if elem, ok := c.items[k]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.value = value
+		entry.expires = expires
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[k] = c.order.PushFront(&lruEntry{productID: productID, key: key, value: value, expires: expires})
+
+	keys, ok := c.byProduct[productID]
+	if !ok {
+		keys = make(map[lruKey]struct{})
+		c.byProduct[productID] = keys
+	}
+	keys[k] = struct{}{}
+
+	for c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *lru) removeProduct(productID model.ID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.epoch++
+
+	for k := range c.byProduct[productID] {
+		c.remove(c.items[k])
+	}
+}
+
+func (c *lru) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *lru) remove(elem *list.Element) {
+	entry := c.order.Remove(elem).(*lruEntry)
+	k := lruKey{entry.productID, entry.key}
+
+	delete(c.items, k)
+
+	keys := c.byProduct[entry.productID]
+	delete(keys, k)
+	if len(keys) == 0 {
+		delete(c.byProduct, entry.productID)
+	}
+}
@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	requests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_requests_total",
+		Help: "Number of cache operations by tier, operation, entity and result.",
+	}, []string{"tier", "op", "entity", "result"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cache_request_duration_seconds",
+		Help:    "Latency of cache operations by tier, operation and entity.",
+		Buckets: []float64{.0001, .0005, .001, .0025, .005, .01, .025, .05, .1, .25},
+	}, []string{"tier", "op", "entity"})
+)
+
+// Results of cache operations. Reads are hits, stale hits, negative hits
+// of tombstones, misses or errors, other operations are ok or errors.
+const (
+	resultHit      = "hit"
+	resultStale    = "stale"
+	resultNegative = "negative"
+	resultMiss     = "miss"
+	resultError    = "error"
+	resultOK       = "ok"
+)
+
+var _ DAO = (*InstrumentedCache)(nil)
+
+// InstrumentedCache records request counts and latencies of another cache.
+// The tier label tells apart the layers of a stack, e.g. local and redis.
+type InstrumentedCache struct {
+	next DAO
+	tier string
+}
+
+func NewInstrumented(next DAO, tier string) *InstrumentedCache {
+	return &InstrumentedCache{next: next, tier: tier}
+}
+
+func (c *InstrumentedCache) observe(op string, entity string, result string, start time.Time) {
+	requests.WithLabelValues(c.tier, op, entity, result).Inc()
+	requestDuration.WithLabelValues(c.tier, op, entity).Observe(time.Since(start).Seconds())
+}
+
+// readResult classifies a read, missing tells whether the value is a tombstone.
+func readResult(err error, missing bool) string {
+	switch {
+	case err == nil && missing:
+		return resultNegative
+	case err == nil:
+		return resultHit
+	case errors.Is(err, Stale):
+		return resultStale
+	case errors.Is(err, NotFound):
+		return resultMiss
+	default:
+		return resultError
+	}
+}
+
+func errorResult(err error) string {
+	if err != nil {
+		return resultError
+	}
+	return resultOK
+}
+
+func (c *InstrumentedCache) InvalidateProduct(ctx context.Context, productID model.ID) {
+	defer c.observe("invalidate", "product", resultOK, time.Now())
+	c.next.InvalidateProduct(ctx, productID)
+}
+
+func (c *InstrumentedCache) ProductGeneration(ctx context.Context, productID model.ID) (Generation, error) {
+	start := time.Now()
+	gen, err := c.next.ProductGeneration(ctx, productID)
+	c.observe("generation", "product", errorResult(err), start)
+	return gen, err
+}
+
+func (c *InstrumentedCache) InvalidateProductListing(ctx context.Context) {
+	defer c.observe("invalidate", "listing", resultOK, time.Now())
+	c.next.InvalidateProductListing(ctx)
+}
+
+func (c *InstrumentedCache) ListingGeneration(ctx context.Context) (Generation, error) {
+	start := time.Now()
+	gen, err := c.next.ListingGeneration(ctx)
+	c.observe("generation", "listing", errorResult(err), start)
+	return gen, err
+}
+
+func (c *InstrumentedCache) GetProduct(ctx context.Context, productID model.ID) (*model.Product, error) {
+	start := time.Now()
+	product, err := c.next.GetProduct(ctx, productID)
+	c.observe("get", "product", readResult(err, product == nil), start)
+	return product, err
+}
+
+func (c *InstrumentedCache) SetProduct(ctx context.Context, productID model.ID, load Load, product *model.Product) {
+	defer c.observe("set", "product", resultOK, time.Now())
+	c.next.SetProduct(ctx, productID, load, product)
+}
+
+func (c *InstrumentedCache) GetProducts(ctx context.Context, offset int, limit int) ([]*model.Product, error) {
+	start := time.Now()
+	products, err := c.next.GetProducts(ctx, offset, limit)
+	c.observe("get", "listing", readResult(err, false), start)
+	return products, err
+}
+
+func (c *InstrumentedCache) SetProducts(ctx context.Context, load Load, offset int, limit int, products []*model.Product) {
+	defer c.observe("set", "listing", resultOK, time.Now())
+	c.next.SetProducts(ctx, load, offset, limit, products)
+}
+
+func (c *InstrumentedCache) GetProductRating(ctx context.Context, productID model.ID) (float32, error) {
+	start := time.Now()
+	rating, err := c.next.GetProductRating(ctx, productID)
+	c.observe("get", "rating", readResult(err, false), start)
+	return rating, err
+}
+
+func (c *InstrumentedCache) SetProductRating(ctx context.Context, productID model.ID, load Load, rating float32) {
+	defer c.observe("set", "rating", resultOK, time.Now())
+	c.next.SetProductRating(ctx, productID, load, rating)
+}
+
+func (c *InstrumentedCache) GetProductReview(ctx context.Context, productID model.ID, reviewID model.ID) (*model.Review, error) {
+	start := time.Now()
+	review, err := c.next.GetProductReview(ctx, productID, reviewID)
+	c.observe("get", "review", readResult(err, review == nil), start)
+	return review, err
+}
+
+func (c *InstrumentedCache) SetProductReview(ctx context.Context, productID model.ID, load Load, reviewID model.ID, review *model.Review) {
+	defer c.observe("set", "review", resultOK, time.Now())
+	c.next.SetProductReview(ctx, productID, load, reviewID, review)
+}
+
+func (c *InstrumentedCache) GetProductReviews(ctx context.Context, productID model.ID, offset int, limit int) ([]*model.Review, error) {
+	start := time.Now()
+	reviews, err := c.next.GetProductReviews(ctx, productID, offset, limit)
+	c.observe("get", "reviews", readResult(err, false), start)
+	return reviews, err
+}
+
+func (c *InstrumentedCache) SetProductReviews(ctx context.Context, productID model.ID, load Load, offset int, limit int, reviews []*model.Review) {
+	defer c.observe("set", "reviews", resultOK, time.Now())
+	c.next.SetProductReviews(ctx, productID, load, offset, limit, reviews)
+}
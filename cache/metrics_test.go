@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestInstrumentedCache_Results(t *testing.T) {
+	next := new(mockedCache)
+	next.On("GetProduct", mock.Anything, 1).Return(&model.Product{ID: 1}, nil).Once()
+	next.On("GetProduct", mock.Anything, 2).Return((*model.Product)(nil), nil).Once()
+	next.On("GetProduct", mock.Anything, 3).Return((*model.Product)(nil), NotFound).Once()
+	next.On("GetProductRating", mock.Anything, 1).Return(float32(4.5), Stale).Once()
+
+	c := NewInstrumented(next, "test")
+
+	for id := range 3 {
+		_, _ = c.GetProduct(t.Context(), id+1)
+	}
+	_, _ = c.GetProductRating(t.Context(), 1)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(requests.WithLabelValues("test", "get", "product", resultHit)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(requests.WithLabelValues("test", "get", "product", resultNegative)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(requests.WithLabelValues("test", "get", "product", resultMiss)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(requests.WithLabelValues("test", "get", "rating", resultStale)))
+	next.AssertExpectations(t)
+}
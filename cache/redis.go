@@ -2,8 +2,10 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"math/rand/v2"
 	"time"
 
 	"github.com/lameaux/golang-product-reviews/model"
@@ -14,146 +16,348 @@ import (
 var _ DAO = (*RedisCache)(nil)
 
 const prefix = "products"
-const ttl = 1 * time.Hour
+
+// Keys include a generation: products:<id>:<generation>:rating.
+// Invalidation increments the generation, so old entries are never read
+// again and expire with the TTL. Generation keys have no TTL.
+//
+// Listing pages span many products and use a global listing generation.
+const listingGenerationKey = prefix + ":listing:generation"
+
+// A missing generation key starts at the Redis time in microseconds rather
+// than 0. If it is evicted, e.g. under an allkeys-* policy, the counter still
+// grows past all earlier generations, so their entries stay unreachable.
+var (
+	initGenerationScript = redis.NewScript(`
+local gen = redis.call("GET", KEYS[1])
+if gen then
+	return gen
+end
+local t = redis.call("TIME")
+gen = t[1] .. string.format("%06d", tonumber(t[2]))
+redis.call("SET", KEYS[1], gen)
+return gen
+`)
+
+	incrGenerationScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	local t = redis.call("TIME")
+	redis.call("SET", KEYS[1], t[1] .. string.format("%06d", tonumber(t[2])))
+end
+return redis.call("INCR", KEYS[1])
+`)
+)
+
+// Fence keys hold the last fencing token written with a product or the listing.
+// They outlive any load, a holder that lost its lock finishes within fenceTTL.
+const (
+	listingFenceKey = prefix + ":listing:fence"
+	fenceTTL        = time.Minute
+)
+
+// fencedSetScript sets the value unless the fence of KEYS[2] is newer than ARGV[2].
+var fencedSetScript = redis.NewScript(`
+local last = tonumber(redis.call("GET", KEYS[2]) or "0")
+local fence = tonumber(ARGV[2])
+if fence < last then
+	return 0
+end
+redis.call("SET", KEYS[2], ARGV[2], "PX", ARGV[4])
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[3])
+return 1
+`)
+
+// errTombstone is returned by get for tombstones.
+var errTombstone = errors.New("tombstone")
 
 type RedisCache struct {
 	logger *zerolog.Logger
 	client *redis.Client
+	cfg    Config
+	format entryFormat
+	now    func() time.Time
+	random func() float64
+}
+
+func NewRedis(logger *zerolog.Logger, client *redis.Client, cfg Config) *RedisCache {
+	return &RedisCache{
+		logger: logger,
+		client: client,
+		cfg:    cfg,
+		format: entryFormat{codec: cfg.Codec, compression: cfg.Compression, compressAbove: cfg.CompressAbove},
+		now:    time.Now,
+		random: rand.Float64,
+	}
+}
+
+func productGenerationKey(productID model.ID) string {
+	return fmt.Sprintf("%s:%d:generation", prefix, productID)
+}
+
+func productFenceKey(productID model.ID) string {
+	return fmt.Sprintf("%s:%d:fence", prefix, productID)
 }
 
-func NewRedis(logger *zerolog.Logger, client *redis.Client) *RedisCache {
-	return &RedisCache{logger: logger, client: client}
+func productKey(productID model.ID, gen Generation, suffix string) string {
+	return fmt.Sprintf("%s:%d:%d:%s", prefix, productID, gen, suffix)
+}
+
+func listingKey(gen Generation, offset int, limit int) string {
+	return fmt.Sprintf("%s:listing:%d:%d:%d", prefix, gen, offset, limit)
 }
 
 func (r *RedisCache) InvalidateProduct(ctx context.Context, productID model.ID) {
-	pattern := fmt.Sprintf("%s:%d:*", prefix, productID)
-
-	var cursor uint64
-	for {
-		keys, next, err := r.client.Scan(ctx, cursor, pattern, 100).Result()
-		if err != nil {
-			r.logger.Warn().Err(err).Str("pattern", pattern).
-				Msg("InvalidateProduct Scan failed")
-			return
-		}
-		if len(keys) > 0 {
-			if err := r.client.Del(ctx, keys...).Err(); err != nil {
-				r.logger.Warn().Err(err).Str("pattern", pattern).
-					Msg("InvalidateProduct Del failed")
-				return
-			}
-		}
-		cursor = next
-		if cursor == 0 {
-			break
-		}
+	key := productGenerationKey(productID)
+
+	generation, err := incrGenerationScript.Run(ctx, r.client, []string{key}).Int64()
+	if err != nil {
+		r.logger.Warn().Err(err).Str("key", key).Msg("InvalidateProduct failed")
+		return
 	}
 
-	r.logger.Debug().Str("pattern", pattern).Msg("InvalidateProduct")
+	r.logger.Debug().Str("key", key).Int64("generation", generation).Msg("InvalidateProduct")
 }
 
-func (r *RedisCache) GetProductRating(ctx context.Context, productID model.ID) (float32, error) {
-	key := fmt.Sprintf("%s:%d:rating", prefix, productID)
+func (r *RedisCache) ProductGeneration(ctx context.Context, productID model.ID) (Generation, error) {
+	return r.generation(ctx, productGenerationKey(productID))
+}
 
-	rating, err := r.client.Get(ctx, key).Float32()
-	if err == redis.Nil {
-		r.logger.Debug().Str("key", key).Msg("GetProductRating not found")
-		return 0, NotFound
-	}
+func (r *RedisCache) GetProductRating(ctx context.Context, productID model.ID) (float32, error) {
+	gen, err := r.ProductGeneration(ctx, productID)
 	if err != nil {
 		return 0, fmt.Errorf("GetProductRating: %w", err)
 	}
 
-	if err := r.client.Expire(ctx, key, ttl).Err(); err != nil {
-		r.logger.Warn().Str("key", key).Msg("GetProductRating ttl refresh failed")
+	var rating float32
+	if err := r.get(ctx, productKey(productID, gen, "rating"), &rating); err != nil {
+		return rating, fmt.Errorf("GetProductRating: %w", err)
 	}
 
-	r.logger.Debug().Str("key", key).Float32("rating", rating).Msg("GetProductRating")
 	return rating, nil
 }
-func (r *RedisCache) SetProductRating(ctx context.Context, productID model.ID, rating float32) {
-	key := fmt.Sprintf("%s:%d:rating", prefix, productID)
+func (r *RedisCache) SetProductRating(ctx context.Context, productID model.ID, load Load, rating float32) {
+	r.set(ctx, productKey(productID, load.Generation, "rating"), productFenceKey(productID), rating, load, r.cfg.Rating)
+}
+
+func (r *RedisCache) GetProductReview(ctx context.Context, productID model.ID, reviewID model.ID) (*model.Review, error) {
+	gen, err := r.ProductGeneration(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("GetProductReview: %w", err)
+	}
 
-	if err := r.client.Set(ctx, key, rating, ttl).Err(); err != nil {
-		r.logger.Warn().Err(err).Str("key", key).Float32("rating", rating).Msg("SetProductRating failed")
+	var review model.Review
+	err = r.get(ctx, productKey(productID, gen, fmt.Sprintf("review:%d", reviewID)), &review)
+	if errors.Is(err, errTombstone) {
+		return nil, nil
+	}
+	if err != nil {
+		return valueUnlessMissing(&review, err), fmt.Errorf("GetProductReview: %w", err)
+	}
+
+	return &review, nil
+}
+func (r *RedisCache) SetProductReview(ctx context.Context, productID model.ID, load Load, reviewID model.ID, review *model.Review) {
+	key := productKey(productID, load.Generation, fmt.Sprintf("review:%d", reviewID))
+	if review == nil {
+		r.setTombstone(ctx, key, productFenceKey(productID), load)
 		return
 	}
 
-	r.logger.Debug().Str("key", key).Float32("rating", rating).Msg("SetProductRating")
+	r.set(ctx, key, productFenceKey(productID), review, load, r.cfg.Review)
 }
 
-func (r *RedisCache) GetProductReview(ctx context.Context, productID model.ID, reviewID model.ID) (*model.Review, error) {
-	key := fmt.Sprintf("%s:%d:review:%d", prefix, productID, reviewID)
+func (r *RedisCache) GetProductReviews(ctx context.Context, productID model.ID, offset int, limit int) ([]*model.Review, error) {
+	gen, err := r.ProductGeneration(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("GetProductReviews: %w", err)
+	}
 
-	bytes, err := r.client.Get(ctx, key).Bytes()
-	if err == redis.Nil {
-		r.logger.Debug().Str("key", key).Msg("GetProductReview not found")
-		return nil, NotFound
+	var reviews []*model.Review
+	if err := r.get(ctx, productKey(productID, gen, fmt.Sprintf("reviews:%d:%d", offset, limit)), &reviews); err != nil {
+		return reviews, fmt.Errorf("GetProductReviews: %w", err)
 	}
+
+	return reviews, nil
+}
+func (r *RedisCache) SetProductReviews(ctx context.Context, productID model.ID, load Load, offset int, limit int, reviews []*model.Review) {
+	r.set(ctx, productKey(productID, load.Generation, fmt.Sprintf("reviews:%d:%d", offset, limit)), productFenceKey(productID), reviews, load, r.cfg.Reviews)
+}
+
+func (r *RedisCache) InvalidateProductListing(ctx context.Context) {
+	generation, err := incrGenerationScript.Run(ctx, r.client, []string{listingGenerationKey}).Int64()
 	if err != nil {
-		return nil, fmt.Errorf("GetProductReview: %w", err)
+		r.logger.Warn().Err(err).Msg("InvalidateProductListing failed")
+		return
 	}
 
-	var review model.Review
-	if err = json.Unmarshal(bytes, &review); err != nil {
-		return nil, fmt.Errorf("GetProductReview unmarshal: %w", err)
+	r.logger.Debug().Int64("generation", generation).Msg("InvalidateProductListing")
+}
+
+func (r *RedisCache) ListingGeneration(ctx context.Context) (Generation, error) {
+	return r.generation(ctx, listingGenerationKey)
+}
+
+func (r *RedisCache) GetProduct(ctx context.Context, productID model.ID) (*model.Product, error) {
+	gen, err := r.ProductGeneration(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("GetProduct: %w", err)
 	}
 
-	r.logger.Debug().Str("key", key).Msg("GetProductReview")
+	var product model.Product
+	err = r.get(ctx, productKey(productID, gen, "product"), &product)
+	if errors.Is(err, errTombstone) {
+		return nil, nil
+	}
+	if err != nil {
+		return valueUnlessMissing(&product, err), fmt.Errorf("GetProduct: %w", err)
+	}
 
-	return &review, nil
+	return &product, nil
 }
-func (r *RedisCache) SetProductReview(ctx context.Context, productID model.ID, reviewID model.ID, review *model.Review) {
-	key := fmt.Sprintf("%s:%d:review:%d", prefix, productID, reviewID)
+func (r *RedisCache) SetProduct(ctx context.Context, productID model.ID, load Load, product *model.Product) {
+	key := productKey(productID, load.Generation, "product")
+	if product == nil {
+		r.setTombstone(ctx, key, productFenceKey(productID), load)
+		return
+	}
 
-	bytes, err := json.Marshal(review)
+	r.set(ctx, key, productFenceKey(productID), product, load, r.cfg.Product)
+}
+
+func (r *RedisCache) GetProducts(ctx context.Context, offset int, limit int) ([]*model.Product, error) {
+	gen, err := r.ListingGeneration(ctx)
 	if err != nil {
-		r.logger.Warn().Err(err).Str("key", key).Msg("SetProductReview marshal failed")
-		return
+		return nil, fmt.Errorf("GetProducts: %w", err)
 	}
 
-	if err := r.client.Set(ctx, key, bytes, ttl).Err(); err != nil {
-		r.logger.Warn().Err(err).Str("key", key).Msg("SetProductReview failed")
-		return
+	var products []*model.Product
+	if err := r.get(ctx, listingKey(gen, offset, limit), &products); err != nil {
+		return products, fmt.Errorf("GetProducts: %w", err)
 	}
 
-	r.logger.Debug().Str("key", key).Msg("SetProductReview")
+	return products, nil
+}
+func (r *RedisCache) SetProducts(ctx context.Context, load Load, offset int, limit int, products []*model.Product) {
+	r.set(ctx, listingKey(load.Generation, offset, limit), listingFenceKey, products, load, r.cfg.Listing)
 }
 
-func (r *RedisCache) GetProductReviews(ctx context.Context, productID model.ID, offset int, limit int) ([]*model.Review, error) {
-	key := fmt.Sprintf("%s:%d:reviews:%d:%d", prefix, productID, offset, limit)
+func (r *RedisCache) generation(ctx context.Context, key string) (Generation, error) {
+	gen, err := r.client.Get(ctx, key).Int64()
+	if err == redis.Nil {
+		gen, err = initGenerationScript.Run(ctx, r.client, []string{key}).Int64()
+	}
+	if err != nil {
+		return 0, fmt.Errorf("generation: %w", err)
+	}
+
+	return Generation(gen), nil
+}
 
+// get reads an envelope into value. A missing key is NotFound,
+// a value due for a refresh is decoded and Stale is returned.
+// Entries that can't be decoded, e.g. written by a newer version, are misses.
+func (r *RedisCache) get(ctx context.Context, key string, value any) error {
 	bytes, err := r.client.Get(ctx, key).Bytes()
 	if err == redis.Nil {
-		r.logger.Debug().Str("key", key).Msg("GetProductReviews not found")
-		return nil, NotFound
+		r.logger.Debug().Str("key", key).Msg("cache miss")
+		return NotFound
 	}
 	if err != nil {
-		return nil, fmt.Errorf("GetProductReviews: %w", err)
+		return err
 	}
 
-	var reviews []*model.Review
-	if err = json.Unmarshal(bytes, &reviews); err != nil {
-		return nil, fmt.Errorf("GetProductReviews unmarshal: %w", err)
+	env, err := parseEnvelope(bytes)
+	if err != nil {
+		r.logger.Warn().Err(err).Str("key", key).Msg("cache entry unreadable")
+		return NotFound
 	}
 
-	r.logger.Debug().Str("key", key).Msg("GetProductReviews")
+	if env.Missing {
+		r.logger.Debug().Str("key", key).Msg("cache tombstone")
+		return errTombstone
+	}
 
-	return reviews, nil
+	if err = env.decode(value); err != nil {
+		r.logger.Warn().Err(err).Str("key", key).Str("codec", env.codec.Name()).Msg("cache entry unreadable")
+		return NotFound
+	}
+
+	if r.refreshEarly(env) {
+		r.logger.Debug().Str("key", key).Msg("cache stale")
+		return Stale
+	}
+
+	r.logger.Debug().Str("key", key).Msg("cache hit")
+
+	return nil
+}
+
+// refreshEarly implements probabilistic early expiration (XFetch): a reader
+// refreshes before the soft expiry with a probability that grows as the
+// expiry gets closer and with the load duration. Past the soft expiry it always does.
+func (r *RedisCache) refreshEarly(env envelope) bool {
+	delta := float64(env.Delta) * r.cfg.Beta * -math.Log(r.random())
+
+	return float64(r.now().UnixMilli())+delta >= float64(env.SoftExpiry)
 }
-func (r *RedisCache) SetProductReviews(ctx context.Context, productID model.ID, offset int, limit int, reviews []*model.Review) {
-	key := fmt.Sprintf("%s:%d:reviews:%d:%d", prefix, productID, offset, limit)
 
-	bytes, err := json.Marshal(reviews)
+func (r *RedisCache) set(ctx context.Context, key string, fenceKey string, value any, load Load, ttl TTL) {
+	bytes, err := r.format.encode(envelope{
+		SoftExpiry: r.now().Add(ttl.Soft).UnixMilli(),
+		Delta:      load.Duration.Milliseconds(),
+	}, value)
 	if err != nil {
-		r.logger.Warn().Err(err).Str("key", key).Msg("SetProductReviews marshal failed")
+		r.logger.Warn().Err(err).Str("key", key).Msg("cache marshal failed")
+		return
+	}
+
+	r.write(ctx, key, fenceKey, bytes, load.Fence, ttl.Hard, "cache set")
+}
+
+// setTombstone marks a missing entity, the tombstone is never stale
+// and is dropped with the product generation when the entity is created.
+func (r *RedisCache) setTombstone(ctx context.Context, key string, fenceKey string, load Load) {
+	bytes, err := r.format.encode(envelope{Missing: true}, nil)
+	if err != nil {
+		r.logger.Warn().Err(err).Str("key", key).Msg("cache marshal failed")
+		return
+	}
+
+	r.write(ctx, key, fenceKey, bytes, load.Fence, r.cfg.Missing, "cache set tombstone")
+}
+
+// write stores the entry. Fenced writes are conditional on the fence key,
+// so a loader that lost its lock can't overwrite the value of the next holder.
+func (r *RedisCache) write(ctx context.Context, key string, fenceKey string, bytes []byte, fence uint64, ttl time.Duration, msg string) {
+	if fence == 0 {
+		if err := r.client.Set(ctx, key, bytes, ttl).Err(); err != nil {
+			r.logger.Warn().Err(err).Str("key", key).Msg(msg + " failed")
+			return
+		}
+
+		r.logger.Debug().Str("key", key).Msg(msg)
 		return
 	}
 
-	if err := r.client.Set(ctx, key, bytes, ttl).Err(); err != nil {
-		r.logger.Warn().Err(err).Str("key", key).Msg("SetProductReviews failed")
+	written, err := fencedSetScript.Run(ctx, r.client, []string{key, fenceKey},
+		bytes, fence, ttl.Milliseconds(), fenceTTL.Milliseconds()).Int()
+	if err != nil {
+		r.logger.Warn().Err(err).Str("key", key).Msg(msg + " failed")
+		return
+	}
+	if written == 0 {
+		r.logger.Debug().Str("key", key).Uint64("fence", fence).Msg(msg + " fenced off")
 		return
 	}
 
-	r.logger.Debug().Str("key", key).Msg("SetProductReviews")
+	r.logger.Debug().Str("key", key).Uint64("fence", fence).Msg(msg)
+}
+
+// valueUnlessMissing keeps a stale value for the caller.
+func valueUnlessMissing[T any](value *T, err error) *T {
+	if err == Stale {
+		return value
+	}
+
+	return nil
 }
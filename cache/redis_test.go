@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisCache_RefreshEarly(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		expiry time.Duration
+		delta  time.Duration
+		random float64
+		want   bool
+	}{
+		{name: "fresh", expiry: time.Minute, delta: 100 * time.Millisecond, random: 0.5, want: false},
+		{name: "past soft expiry", expiry: -time.Second, delta: 100 * time.Millisecond, random: 1, want: true},
+		{name: "slow load close to expiry", expiry: time.Second, delta: time.Second, random: 0.1, want: true},
+		{name: "fast load close to expiry", expiry: time.Second, delta: 10 * time.Millisecond, random: 0.1, want: false},
+		{name: "unlucky draw", expiry: time.Minute, delta: time.Second, random: math.Exp(-61), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRedis(&log.Logger, nil, DefaultConfig())
+			r.now = func() time.Time { return now }
+			r.random = func() float64 { return tt.random }
+
+			env := envelope{
+				SoftExpiry: now.Add(tt.expiry).UnixMilli(),
+				Delta:      tt.delta.Milliseconds(),
+			}
+
+			assert.Equal(t, tt.want, r.refreshEarly(env))
+		})
+	}
+}
+
+func TestRedisCache_FencedSet(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	r := NewRedis(&log.Logger, client, DefaultConfig())
+	r.random = func() float64 { return 0.5 }
+
+	gen, err := r.ProductGeneration(t.Context(), 1)
+	require.NoError(t, err)
+
+	current := &model.Product{ID: 1, Name: "current"}
+	stale := &model.Product{ID: 1, Name: "stale"}
+
+	r.SetProduct(t.Context(), 1, Load{Generation: gen, Fence: 2}, current)
+	// the holder of an older token lost its lock, its write is rejected
+	r.SetProduct(t.Context(), 1, Load{Generation: gen, Fence: 1}, stale)
+
+	product, err := r.GetProduct(t.Context(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, current, product)
+
+	fence, err := mr.Get(productFenceKey(1))
+	require.NoError(t, err)
+	assert.Equal(t, "2", fence)
+	assert.Positive(t, mr.TTL(productFenceKey(1)))
+
+	// a tombstone with an older token is rejected too
+	r.SetProduct(t.Context(), 1, Load{Generation: gen, Fence: 1}, nil)
+	product, err = r.GetProduct(t.Context(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, current, product)
+
+	// the same or a newer token overwrites the value
+	r.SetProduct(t.Context(), 1, Load{Generation: gen, Fence: 3}, stale)
+	product, err = r.GetProduct(t.Context(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, stale, product)
+
+	// unfenced writes aren't checked
+	r.SetProduct(t.Context(), 1, Load{Generation: gen}, current)
+	product, err = r.GetProduct(t.Context(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, current, product)
+}
+
+func TestRedisCache_GenerationEvicted(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	r := NewRedis(&log.Logger, client, DefaultConfig())
+
+	first, err := r.ProductGeneration(t.Context(), 1)
+	require.NoError(t, err)
+	assert.Positive(t, first)
+
+	again, err := r.ProductGeneration(t.Context(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+
+	r.InvalidateProduct(t.Context(), 1)
+	r.InvalidateProduct(t.Context(), 1)
+	invalidated, err := r.ProductGeneration(t.Context(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, first+2, invalidated)
+
+	// an evicted counter restarts past all earlier generations
+	mr.Del(productGenerationKey(1))
+	mr.SetTime(time.Now().Add(time.Second))
+	r.InvalidateProduct(t.Context(), 1)
+	restarted, err := r.ProductGeneration(t.Context(), 1)
+	require.NoError(t, err)
+	assert.Greater(t, restarted, invalidated)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+
+	cfg := DefaultConfig()
+	cfg.Rating = TTL{Soft: time.Hour, Hard: time.Minute}
+	assert.Error(t, cfg.Validate())
+
+	cfg = DefaultConfig()
+	cfg.Missing = 0
+	assert.Error(t, cfg.Validate())
+}
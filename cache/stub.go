@@ -0,0 +1,26 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/lameaux/golang-product-reviews/model"
+)
+
+var _ Admin = (*StubAdmin)(nil)
+
+type StubAdmin struct {
+	Generation Generation
+	Entries    []Entry
+}
+
+func (s *StubAdmin) ProductEntries(ctx context.Context, productID model.ID) (Generation, []Entry, error) {
+	return s.Generation, s.Entries, nil
+}
+
+func (s *StubAdmin) FlushProduct(ctx context.Context, productID model.ID) (int, error) {
+	return len(s.Entries), nil
+}
+
+func (s *StubAdmin) FlushAll(ctx context.Context) (int, error) {
+	return len(s.Entries), nil
+}
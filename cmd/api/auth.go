@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	httpapi "github.com/lameaux/golang-product-reviews/api/http"
+	"github.com/lameaux/golang-product-reviews/productmanager"
+)
+
+// getAuth reads the bearer tokens of AUTH_TOKENS and, with MANAGER_AUTHZ=true,
+// the principals of AUTHZ_WRITERS and AUTHZ_ADMINS.
+func getAuth() (*httpapi.Tokens, productmanager.Authorizer, error) {
+	var tokens *httpapi.Tokens
+	if val := os.Getenv("AUTH_TOKENS"); val != "" {
+		var err error
+		if tokens, err = httpapi.ParseTokens(val); err != nil {
+			return nil, nil, fmt.Errorf("invalid AUTH_TOKENS: %w", err)
+		}
+	}
+
+	if os.Getenv("MANAGER_AUTHZ") != "true" {
+		return tokens, nil, nil
+	}
+
+	if tokens == nil {
+		return nil, nil, errors.New("MANAGER_AUTHZ needs AUTH_TOKENS to authenticate callers")
+	}
+
+	authorizer := productmanager.NewRoleAuthorizer(
+		splitList(os.Getenv("AUTHZ_WRITERS")),
+		splitList(os.Getenv("AUTHZ_ADMINS")),
+	)
+
+	return tokens, authorizer, nil
+}
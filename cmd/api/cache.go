@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/cache"
+	"github.com/lameaux/golang-product-reviews/dto"
+	"github.com/lameaux/golang-product-reviews/productmanager"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+// setupLocalCache puts an in-process LRU in front of Redis, unless LOCAL_CACHE_SIZE is 0.
+func setupLocalCache(ctx context.Context, logger *zerolog.Logger, rdb *redis.Client, next cache.DAO) (cache.DAO, error) {
+	size := cache.DefaultLocalSize
+	if val := os.Getenv("LOCAL_CACHE_SIZE"); val != "" {
+		var err error
+		if size, err = strconv.Atoi(val); err != nil || size < 0 {
+			return nil, fmt.Errorf("invalid LOCAL_CACHE_SIZE: %q", val)
+		}
+	}
+
+	if size == 0 {
+		return next, nil
+	}
+
+	ttl := cache.DefaultLocalTTL
+	if val := os.Getenv("LOCAL_CACHE_TTL"); val != "" {
+		var err error
+		if ttl, err = time.ParseDuration(val); err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("invalid LOCAL_CACHE_TTL: %q", val)
+		}
+	}
+
+	bus := cache.NewRedisInvalidationBus(logger, rdb, cache.DefaultInvalidationChannel)
+	localCache := cache.NewLocal(logger, next, bus, size, ttl)
+
+	go func() {
+		if err := localCache.Run(ctx); err != nil {
+			logger.Error().Err(err).Msg("local cache invalidation listener failed")
+		}
+	}()
+
+	return cache.NewInstrumented(localCache, "local"), nil
+}
+
+// getCacheConfig reads CACHE_TTL_<ENTITY> as "<soft>/<hard>", e.g. CACHE_TTL_RATING=5m/1h.
+func getCacheConfig() (cache.Config, error) {
+	cfg := cache.DefaultConfig()
+
+	for name, ttl := range map[string]*cache.TTL{
+		"PRODUCT": &cfg.Product,
+		"LISTING": &cfg.Listing,
+		"RATING":  &cfg.Rating,
+		"REVIEW":  &cfg.Review,
+		"REVIEWS": &cfg.Reviews,
+	} {
+		key := "CACHE_TTL_" + name
+		val := os.Getenv(key)
+		if val == "" {
+			continue
+		}
+
+		soft, hard, ok := strings.Cut(val, "/")
+		if !ok {
+			return cfg, fmt.Errorf("invalid %s: %q, expected <soft>/<hard>", key, val)
+		}
+
+		var err error
+		if ttl.Soft, err = time.ParseDuration(soft); err != nil {
+			return cfg, fmt.Errorf("invalid %s: %w", key, err)
+		}
+		if ttl.Hard, err = time.ParseDuration(hard); err != nil {
+			return cfg, fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+
+	if val := os.Getenv("CACHE_TTL_MISSING"); val != "" {
+		missing, err := time.ParseDuration(val)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid CACHE_TTL_MISSING: %w", err)
+		}
+		cfg.Missing = missing
+	}
+
+	if val := os.Getenv("CACHE_BETA"); val != "" {
+		beta, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid CACHE_BETA: %q", val)
+		}
+		cfg.Beta = beta
+	}
+
+	if val := os.Getenv("CACHE_CODEC"); val != "" {
+		codec, err := cache.CodecByName(val)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid CACHE_CODEC: %w", err)
+		}
+		cfg.Codec = codec
+	}
+
+	if val := os.Getenv("CACHE_COMPRESSION"); val != "" {
+		compression, err := cache.ParseCompression(val)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid CACHE_COMPRESSION: %w", err)
+		}
+		cfg.Compression = compression
+	}
+
+	if val := os.Getenv("CACHE_COMPRESS_ABOVE"); val != "" {
+		threshold, err := strconv.Atoi(val)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid CACHE_COMPRESS_ABOVE: %q", val)
+		}
+		cfg.CompressAbove = threshold
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// getWarmRequest reads the startup warm-up, CACHE_WARM_ON_START=false disables it.
+func getWarmRequest() (*dto.CacheWarmRequest, error) {
+	if os.Getenv("CACHE_WARM_ON_START") == "false" {
+		return nil, nil
+	}
+
+	req := &dto.CacheWarmRequest{
+		By:          productmanager.WarmByReviews,
+		Limit:       productmanager.DefaultWarmLimit,
+		Concurrency: productmanager.DefaultWarmConcurrency,
+	}
+
+	// traffic is counted by the running replica, there is none at startup
+	if val := os.Getenv("CACHE_WARM_BY"); val != "" && val != productmanager.WarmByReviews {
+		return nil, fmt.Errorf("invalid CACHE_WARM_BY: %q, only %q is known at startup", val, productmanager.WarmByReviews)
+	}
+
+	for key, dst := range map[string]*int{
+		"CACHE_WARM_LIMIT":       &req.Limit,
+		"CACHE_WARM_CONCURRENCY": &req.Concurrency,
+	} {
+		val := os.Getenv(key)
+		if val == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid %s: %q", key, val)
+		}
+		*dst = n
+	}
+
+	return req, nil
+}
+
+// warmCache preloads the cache in the background, the API serves requests meanwhile.
+func warmCache(ctx context.Context, logger *zerolog.Logger, manager productmanager.Manager, req *dto.CacheWarmRequest) {
+	go func() {
+		start := time.Now()
+
+		result, err := manager.WarmCache(ctx, req)
+		if err != nil {
+			logger.Warn().Err(err).Msg("cache warm-up failed")
+			return
+		}
+
+		logger.Info().
+			Str("by", result.By).
+			Int("products", result.Products).
+			Int("failed", result.Failed).
+			Dur("duration", time.Since(start)).
+			Msg("cache warmed up")
+	}()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/lock"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+// setupLock selects the single-flight lock with LOCK_BACKEND:
+// redis (default) or postgres for several replicas, local for a single one.
+// Distributed locks are layered under a local one, so concurrent misses of a
+// replica wait in-process and only one of them takes the distributed lock.
+func setupLock(logger *zerolog.Logger, rdb *redis.Client, sqlDB *sql.DB) (lock.Lock, func(), error) {
+	retry, err := getLockRetry()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch backend := os.Getenv("LOCK_BACKEND"); backend {
+	case "", "redis":
+		return lock.NewLayered(lock.NewLocal(), lock.NewRedis(logger, rdb, retry)), func() {}, nil
+	case "postgres":
+		pgLock := lock.NewPostgres(logger, sqlDB, retry, lock.DefaultKeepAlive)
+		return lock.NewLayered(lock.NewLocal(), pgLock), pgLock.Close, nil
+	case "local":
+		return lock.NewLocal(), func() {}, nil
+	default:
+		return nil, nil, fmt.Errorf("invalid LOCK_BACKEND: %q", backend)
+	}
+}
+
+// getLockRetry reads LOCK_RETRY_ATTEMPTS, LOCK_RETRY_BACKOFF, LOCK_RETRY_MAX_BACKOFF and LOCK_RETRY_JITTER.
+func getLockRetry() (lock.Retry, error) {
+	retry := lock.DefaultRetry()
+
+	if val := os.Getenv("LOCK_RETRY_ATTEMPTS"); val != "" {
+		attempts, err := strconv.Atoi(val)
+		if err != nil {
+			return retry, fmt.Errorf("invalid LOCK_RETRY_ATTEMPTS: %q", val)
+		}
+		retry.Attempts = attempts
+	}
+
+	if val := os.Getenv("LOCK_RETRY_JITTER"); val != "" {
+		jitter, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return retry, fmt.Errorf("invalid LOCK_RETRY_JITTER: %q", val)
+		}
+		retry.Jitter = jitter
+	}
+
+	for key, dst := range map[string]*time.Duration{
+		"LOCK_RETRY_BACKOFF":     &retry.Backoff,
+		"LOCK_RETRY_MAX_BACKOFF": &retry.MaxBackoff,
+	} {
+		val := os.Getenv(key)
+		if val == "" {
+			continue
+		}
+
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return retry, fmt.Errorf("invalid %s: %w", key, err)
+		}
+		*dst = d
+	}
+
+	if err := retry.Validate(); err != nil {
+		return retry, fmt.Errorf("invalid lock retry: %w", err)
+	}
+
+	return retry, nil
+}
@@ -11,12 +11,12 @@ import (
 	httpapi "github.com/lameaux/golang-product-reviews/api/http"
 	"github.com/lameaux/golang-product-reviews/cache"
 	"github.com/lameaux/golang-product-reviews/database"
-	"github.com/lameaux/golang-product-reviews/lock"
-	"github.com/lameaux/golang-product-reviews/notifier"
+	"github.com/lameaux/golang-product-reviews/feed"
 	"github.com/lameaux/golang-product-reviews/productmanager"
-	"github.com/nats-io/nats.go"
+	"github.com/lameaux/golang-product-reviews/webhook"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -43,35 +43,111 @@ func main() {
 }
 
 func run(ctx context.Context, logger *zerolog.Logger) error {
-	dao, err := setupDatabase()
+	gormDB, err := setupDatabase()
 	if err != nil {
 		return fmt.Errorf("setupDatabase: %w", err)
 	}
 
+	dao := database.NewPostgresDAO(gormDB)
+	webhookDAO := database.NewPostgresWebhookDAO(gormDB)
+
 	rdb, err := setupRedis(ctx)
 	if err != nil {
 		return fmt.Errorf("setupRedis: %w", err)
 	}
 
-	redisCache := cache.NewRedis(logger, rdb)
-	redisLock := lock.NewRedis(logger, rdb)
+	cacheConfig, err := getCacheConfig()
+	if err != nil {
+		return fmt.Errorf("invalid cache config: %w", err)
+	}
+
+	redisCache := cache.NewRedis(logger, rdb, cacheConfig)
+
+	cacheDAO, err := setupLocalCache(ctx, logger, rdb, cache.NewInstrumented(redisCache, "redis"))
+	if err != nil {
+		return fmt.Errorf("setupLocalCache: %w", err)
+	}
+	cacheAdmin := cache.NewRedisAdmin(logger, rdb, cacheDAO)
+
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return fmt.Errorf("get sql db: %w", err)
+	}
 
-	nc, err := setupNats()
+	productLock, closeLock, err := setupLock(logger, rdb, sqlDB)
 	if err != nil {
-		return fmt.Errorf("setupNats: %w", err)
+		return fmt.Errorf("setupLock: %w", err)
 	}
-	defer nc.Close()
+	defer closeLock()
 
-	reviewNotifier := notifier.New(logger, nc)
+	webhookConfig, err := getWebhookConfig()
+	if err != nil {
+		return fmt.Errorf("invalid webhook config: %w", err)
+	}
 
-	manager := productmanager.New(dao, redisCache, redisLock, reviewNotifier.Notify)
+	dispatcher := webhook.NewDispatcher(logger, webhookDAO, webhookConfig)
+	go func() {
+		if err := dispatcher.Run(ctx); err != nil {
+			logger.Error().Err(err).Msg("webhook dispatcher failed")
+		}
+	}()
+
+	feedBufferSize, err := getFeedBufferSize()
+	if err != nil {
+		return fmt.Errorf("invalid feed config: %w", err)
+	}
+
+	broker := feed.NewBroker(feedBufferSize)
+
+	eventNotifier, closeNotifier, err := setupNotifier(ctx, logger, rdb, dispatcher, broker)
+	if err != nil {
+		return fmt.Errorf("setupNotifier: %w", err)
+	}
+	defer closeNotifier()
+
+	daoManager := productmanager.New(dao, cacheDAO, productLock, eventNotifier)
+	daoManager.SetLogger(logger)
+
+	warmRequest, err := getWarmRequest()
+	if err != nil {
+		return fmt.Errorf("invalid cache warm-up config: %w", err)
+	}
+	if warmRequest != nil {
+		// the startup warm-up is internal, it isn't authorized or timed out
+		warmCache(ctx, logger, daoManager, warmRequest)
+	}
+
+	tokens, authorizer, err := getAuth()
+	if err != nil {
+		return fmt.Errorf("invalid auth config: %w", err)
+	}
+
+	manager, closeManager, err := decorateManager(logger, daoManager, authorizer)
+	if err != nil {
+		return fmt.Errorf("decorateManager: %w", err)
+	}
+	defer closeManager()
+
+	webhookManager := webhook.NewManager(webhookDAO)
 
 	httpPort, err := getHttpPort()
 	if err != nil {
 		return fmt.Errorf("invalid port: %w", err)
 	}
 
-	httpServer := httpapi.New(httpPort, logger, manager)
+	httpServer := httpapi.New(httpPort, logger, manager, webhookManager, cacheAdmin, broker)
+
+	if tokens != nil {
+		httpServer.SetAuth(tokens, authorizer)
+	}
+
+	validation, err := getOpenAPIValidation()
+	if err != nil {
+		return err
+	}
+	if err := httpServer.SetValidation(validation); err != nil {
+		return fmt.Errorf("httpServer.SetValidation: %w", err)
+	}
 
 	httpErrCh := make(chan error, 1)
 	go func() {
@@ -94,7 +170,7 @@ func run(ctx context.Context, logger *zerolog.Logger) error {
 	return nil
 }
 
-func setupDatabase() (database.DAO, error) {
+func setupDatabase() (*gorm.DB, error) {
 	gormDB, sqlDB, err := database.Connect(os.Getenv("POSTGRES_URL"))
 	if err != nil {
 		return nil, fmt.Errorf("connect to database: %w", err)
@@ -106,12 +182,7 @@ func setupDatabase() (database.DAO, error) {
 		}
 	}
 
-	return database.NewPostgresDAO(gormDB), nil
-}
-
-func setupNats() (*nats.Conn, error) {
-	natsURL := os.Getenv("NATS_URL")
-	return nats.Connect(natsURL)
+	return gormDB, nil
 }
 
 func setupRedis(ctx context.Context) (*redis.Client, error) {
@@ -128,6 +199,48 @@ func setupRedis(ctx context.Context) (*redis.Client, error) {
 	return rdb, nil
 }
 
+func getWebhookConfig() (webhook.Config, error) {
+	cfg := webhook.DefaultConfig()
+
+	if val := os.Getenv("WEBHOOK_MAX_ATTEMPTS"); val != "" {
+		maxAttempts, err := strconv.Atoi(val)
+		if err != nil || maxAttempts < 1 {
+			return cfg, fmt.Errorf("invalid WEBHOOK_MAX_ATTEMPTS: %q", val)
+		}
+		cfg.MaxAttempts = maxAttempts
+	}
+
+	return cfg, nil
+}
+
+func getFeedBufferSize() (int, error) {
+	val := os.Getenv("FEED_BUFFER_SIZE")
+	if val == "" {
+		return feed.DefaultBufferSize, nil
+	}
+
+	size, err := strconv.Atoi(val)
+	if err != nil || size < 1 {
+		return 0, fmt.Errorf("invalid FEED_BUFFER_SIZE: %q", val)
+	}
+
+	return size, nil
+}
+
+func getOpenAPIValidation() (httpapi.Validation, error) {
+	val := os.Getenv("OPENAPI_VALIDATION")
+	if val == "" {
+		return httpapi.ValidationOff, nil
+	}
+
+	validation, err := httpapi.ParseValidation(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid OPENAPI_VALIDATION: %w", err)
+	}
+
+	return validation, nil
+}
+
 func getHttpPort() (int, error) {
 	port := os.Getenv("PORT")
 	if port == "" {
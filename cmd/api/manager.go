@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/productmanager"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// decorateManager wraps the manager with the decorators enabled by
+// MANAGER_TRACING, MANAGER_METRICS, MANAGER_LOGGING and MANAGER_TIMEOUT,
+// and with the authorizer if it is set. The returned func flushes pending spans.
+func decorateManager(
+	logger *zerolog.Logger,
+	manager productmanager.Manager,
+	authorizer productmanager.Authorizer,
+) (productmanager.Manager, func(), error) {
+	var decorators []productmanager.Decorator
+	closer := func() {}
+
+	exporter, err := getSpanExporter()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if exporter != nil {
+		provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+		otel.SetTracerProvider(provider)
+		closer = func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			if err := provider.Shutdown(ctx); err != nil {
+				logger.Warn().Err(err).Msg("tracer provider shutdown failed")
+			}
+		}
+
+		decorators = append(decorators, productmanager.Tracing(otel.Tracer("productmanager")))
+	}
+
+	if os.Getenv("MANAGER_METRICS") != "false" {
+		decorators = append(decorators, productmanager.Metrics())
+	}
+
+	if os.Getenv("MANAGER_LOGGING") == "true" {
+		decorators = append(decorators, productmanager.Logging(logger))
+	}
+
+	if authorizer != nil {
+		decorators = append(decorators, productmanager.Authorization(authorizer))
+	}
+
+	if val := os.Getenv("MANAGER_TIMEOUT"); val != "" {
+		timeout, err := time.ParseDuration(val)
+		if err != nil || timeout < 0 {
+			closer()
+			return nil, nil, fmt.Errorf("invalid MANAGER_TIMEOUT: %q", val)
+		}
+
+		if timeout > 0 {
+			decorators = append(decorators, productmanager.Timeout(timeout))
+		}
+	}
+
+	return productmanager.Decorate(manager, decorators...), closer, nil
+}
+
+// getSpanExporter selects the exporter of MANAGER_TRACING: true or otlp sends
+// spans over OTLP/HTTP, configured with the standard OTEL_EXPORTER_OTLP_* variables,
+// stdout prints them for debugging. It returns nil if tracing is off.
+func getSpanExporter() (sdktrace.SpanExporter, error) {
+	switch val := os.Getenv("MANAGER_TRACING"); val {
+	case "", "false":
+		return nil, nil
+	case "true", "otlp":
+		exporter, err := otlptracehttp.New(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("otlptracehttp.New: %w", err)
+		}
+		return exporter, nil
+	case "stdout":
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("stdouttrace.New: %w", err)
+		}
+		return exporter, nil
+	default:
+		return nil, fmt.Errorf("invalid MANAGER_TRACING: %q", val)
+	}
+}
+
+// splitList splits a comma-separated list, empty items are skipped.
+func splitList(val string) []string {
+	var items []string
+	for item := range strings.SplitSeq(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/feed"
+	"github.com/lameaux/golang-product-reviews/notifier"
+	"github.com/lameaux/golang-product-reviews/webhook"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+const flushTimeout = 10 * time.Second
+
+// setupNotifier creates the backends listed in NOTIFIER_BACKENDS (nats by default),
+// several backends are combined with a fan-out. The webhook dispatcher always gets
+// the events, otherwise subscriptions would silently never fire.
+// The live feed is fed from the nats stream.
+// Unless NOTIFIER_ASYNC is false, events are queued and sent in the background.
+func setupNotifier(
+	ctx context.Context,
+	logger *zerolog.Logger,
+	rdb *redis.Client,
+	dispatcher *webhook.Dispatcher,
+	broker *feed.Broker,
+) (notifier.Notifier, func(), error) {
+	backends := os.Getenv("NOTIFIER_BACKENDS")
+	if backends == "" {
+		backends = "nats"
+	}
+
+	var (
+		notifiers = []notifier.Notifier{dispatcher}
+		closers   []func()
+	)
+
+	closeAll := func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i]()
+		}
+	}
+
+	for _, backend := range strings.Split(backends, ",") {
+		switch strings.TrimSpace(backend) {
+		case "nats":
+			nc, err := setupNats()
+			if err != nil {
+				closeAll()
+				return nil, nil, fmt.Errorf("setupNats: %w", err)
+			}
+			closers = append(closers, nc.Close)
+
+			js, err := setupJetStream(ctx, nc)
+			if err != nil {
+				closeAll()
+				return nil, nil, fmt.Errorf("setupJetStream: %w", err)
+			}
+
+			notifiers = append(notifiers, notifier.NewNATS(logger, js))
+
+			go func() {
+				if err := feed.Consume(ctx, logger, js, broker); err != nil {
+					logger.Error().Err(err).Msg("live feed failed")
+				}
+			}()
+		case "redis":
+			stream := os.Getenv("NOTIFIER_REDIS_STREAM")
+			if stream == "" {
+				stream = "reviews"
+			}
+
+			notifiers = append(notifiers, notifier.NewRedis(logger, rdb, stream))
+		case "file":
+			path := os.Getenv("NOTIFIER_FILE")
+			if path == "" {
+				path = "events.jsonl"
+			}
+
+			fileNotifier, err := notifier.NewFile(logger, path)
+			if err != nil {
+				closeAll()
+				return nil, nil, fmt.Errorf("NewFile: %w", err)
+			}
+			closers = append(closers, func() {
+				if err := fileNotifier.Close(); err != nil {
+					logger.Error().Err(err).Msg("close notifier file")
+				}
+			})
+
+			notifiers = append(notifiers, fileNotifier)
+		case "log":
+			notifiers = append(notifiers, notifier.NewLog(logger))
+		case "webhook":
+			// kept for existing configs, the dispatcher is always added
+		default:
+			closeAll()
+			return nil, nil, fmt.Errorf("unknown notifier backend: %q", backend)
+		}
+	}
+
+	logger.Info().Str("backends", backends).Msg("notifier configured")
+
+	var result notifier.Notifier = notifier.NewFanOut(notifiers...)
+	if len(notifiers) == 1 {
+		result = notifiers[0]
+	}
+
+	if os.Getenv("NOTIFIER_ASYNC") == "false" {
+		return result, closeAll, nil
+	}
+
+	asyncConfig, err := getAsyncConfig()
+	if err != nil {
+		closeAll()
+		return nil, nil, fmt.Errorf("invalid async notifier config: %w", err)
+	}
+
+	asyncNotifier, err := notifier.NewAsync(logger, result, asyncConfig)
+	if err != nil {
+		closeAll()
+		return nil, nil, fmt.Errorf("NewAsync: %w", err)
+	}
+
+	closeAsync := func() {
+		// queued events are sent before the backends are closed
+		ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+		defer cancel()
+
+		if err := asyncNotifier.Close(ctx); err != nil {
+			logger.Error().Err(err).Msg("notifier flush failed")
+		}
+
+		closeAll()
+	}
+
+	return asyncNotifier, closeAsync, nil
+}
+
+func getAsyncConfig() (notifier.AsyncConfig, error) {
+	cfg := notifier.DefaultAsyncConfig()
+
+	if val := os.Getenv("NOTIFIER_QUEUE_SIZE"); val != "" {
+		size, err := strconv.Atoi(val)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid NOTIFIER_QUEUE_SIZE: %q", val)
+		}
+		cfg.QueueSize = size
+	}
+
+	if val := os.Getenv("NOTIFIER_WORKERS"); val != "" {
+		workers, err := strconv.Atoi(val)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid NOTIFIER_WORKERS: %q", val)
+		}
+		cfg.Workers = workers
+	}
+
+	if val := os.Getenv("NOTIFIER_OVERFLOW"); val != "" {
+		cfg.Overflow = notifier.OverflowPolicy(val)
+	}
+
+	if val := os.Getenv("NOTIFIER_SPILL_FILE"); val != "" {
+		cfg.SpillPath = val
+	}
+
+	return cfg, cfg.Validate()
+}
+
+func setupNats() (*nats.Conn, error) {
+	natsURL := os.Getenv("NATS_URL")
+	return nats.Connect(natsURL)
+}
+
+func setupJetStream(ctx context.Context, nc *nats.Conn) (jetstream.JetStream, error) {
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return nil, fmt.Errorf("jetstream.New: %w", err)
+	}
+
+	maxAge := notifier.DefaultStreamMaxAge
+	if val := os.Getenv("NATS_STREAM_MAX_AGE"); val != "" {
+		if maxAge, err = time.ParseDuration(val); err != nil {
+			return nil, fmt.Errorf("invalid NATS_STREAM_MAX_AGE: %w", err)
+		}
+	}
+
+	if _, err := notifier.EnsureStream(ctx, js, maxAge); err != nil {
+		return nil, fmt.Errorf("EnsureStream: %w", err)
+	}
+
+	return js, nil
+}
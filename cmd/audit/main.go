@@ -2,17 +2,29 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
+	"github.com/lameaux/golang-product-reviews/audit"
+	"github.com/lameaux/golang-product-reviews/database"
+	"github.com/lameaux/golang-product-reviews/notifier"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 )
 
 func main() {
+	replayFrom := flag.String("replay-from", "", "rebuild the audit store from stream history, "+
+		"starting at a stream sequence or RFC 3339 time")
+	flag.Parse()
+
 	zerolog.SetGlobalLevel(zerolog.InfoLevel)
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 
@@ -21,26 +33,135 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	if err := run(ctx, &log.Logger, *replayFrom); err != nil {
+		log.Error().Err(err).Msg("start failed")
+	}
+
+	log.Info().Msg("audit stopped")
+}
+
+func run(ctx context.Context, logger *zerolog.Logger, replayFrom string) error {
+	dao, err := setupDatabase()
+	if err != nil {
+		return fmt.Errorf("setupDatabase: %w", err)
+	}
+
+	recorder := audit.NewRecorder(logger, dao)
+
 	natsURL := os.Getenv("NATS_URL")
 
 	nc, err := nats.Connect(natsURL)
 	if err != nil {
-		log.Error().Str("url", natsURL).Err(err).Msg("NATS connect failed")
+		return fmt.Errorf("NATS connect %s: %w", natsURL, err)
 	}
 	defer nc.Close()
 
-	log.Info().Str("url", natsURL).Msg("connected to NATS")
+	logger.Info().Str("url", natsURL).Msg("connected to NATS")
 
-	sub, err := nc.Subscribe("reviews", func(msg *nats.Msg) {
-		log.Info().Str("data", string(msg.Data)).Msg("review updated")
-	})
+	js, err := setupJetStream(ctx, nc)
 	if err != nil {
-		log.Error().Err(err).Msg("subscribe failed")
+		return fmt.Errorf("setupJetStream: %w", err)
 	}
-	defer sub.Unsubscribe()
 
-	log.Info().Msg("audit started")
+	consumer := audit.NewConsumer(logger, js, recorder)
 
-	<-ctx.Done()
-	log.Info().Msg("audit stopped")
+	if replayFrom != "" {
+		from, err := audit.ParseReplayFrom(replayFrom)
+		if err != nil {
+			return fmt.Errorf("invalid replay-from: %w", err)
+		}
+
+		count, err := consumer.Replay(ctx, from)
+		if err != nil {
+			return fmt.Errorf("replay: %w", err)
+		}
+
+		logger.Info().Int("count", count).Msg("replay finished")
+	}
+
+	consumerErrCh := make(chan error, 1)
+	go func() {
+		consumerErrCh <- consumer.Run(ctx)
+	}()
+
+	httpPort, err := getHttpPort()
+	if err != nil {
+		return fmt.Errorf("invalid port: %w", err)
+	}
+
+	httpServer := audit.NewServer(httpPort, logger, dao)
+
+	httpErrCh := make(chan error, 1)
+	go func() {
+		httpErrCh <- httpServer.Serve()
+	}()
+	defer httpServer.Stop()
+
+	logger.Info().Msg("audit started")
+
+	select {
+	case <-ctx.Done():
+		logger.Info().Msg("audit shutting down")
+	case err := <-httpErrCh:
+		if err != nil {
+			return fmt.Errorf("http server error: %w", err)
+		}
+		logger.Info().Msg("http server stopped")
+	case err := <-consumerErrCh:
+		if err != nil {
+			return fmt.Errorf("consumer error: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func setupDatabase() (database.AuditDAO, error) {
+	gormDB, sqlDB, err := database.Connect(os.Getenv("POSTGRES_URL"))
+	if err != nil {
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+
+	if os.Getenv("RUN_MIGRATIONS") == "true" {
+		if err := database.Migrate(sqlDB); err != nil {
+			return nil, fmt.Errorf("migrations failed: %w", err)
+		}
+	}
+
+	return database.NewPostgresAuditDAO(gormDB), nil
+}
+
+func setupJetStream(ctx context.Context, nc *nats.Conn) (jetstream.JetStream, error) {
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return nil, fmt.Errorf("jetstream.New: %w", err)
+	}
+
+	maxAge := notifier.DefaultStreamMaxAge
+	if val := os.Getenv("NATS_STREAM_MAX_AGE"); val != "" {
+		if maxAge, err = time.ParseDuration(val); err != nil {
+			return nil, fmt.Errorf("invalid NATS_STREAM_MAX_AGE: %w", err)
+		}
+	}
+
+	// the audit service may start before the api, so it ensures the stream as well
+	if _, err := notifier.EnsureStream(ctx, js, maxAge); err != nil {
+		return nil, fmt.Errorf("EnsureStream: %w", err)
+	}
+
+	return js, nil
+}
+
+func getHttpPort() (int, error) {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	httpPort, err := strconv.Atoi(port)
+	if err != nil {
+		return 0, fmt.Errorf("invalid port: %w", err)
+	}
+
+	return httpPort, nil
 }
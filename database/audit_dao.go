@@ -0,0 +1,24 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/model"
+)
+
+// AuditFilter narrows down audit events, zero values are ignored.
+type AuditFilter struct {
+	ProductID model.ID
+	ReviewID  model.ID
+	Action    string
+	From      time.Time
+	To        time.Time
+	Offset    int
+	Limit     int
+}
+
+type AuditDAO interface {
+	CreateAuditEvent(ctx context.Context, event *model.AuditEvent) error
+	ListAuditEvents(ctx context.Context, filter *AuditFilter) ([]*model.AuditEvent, error)
+}
@@ -9,14 +9,27 @@ import (
 type DAO interface {
 	CreateProduct(ctx context.Context, p *model.Product) (model.ID, error)
 	UpdateProduct(ctx context.Context, p *model.Product) error
+	// PatchProduct updates only the given fields of the product, e.g. "Price".
+	PatchProduct(ctx context.Context, p *model.Product, fields []string) error
 	DeleteProduct(ctx context.Context, id model.ID) error
 	GetProduct(ctx context.Context, id model.ID) (*model.Product, error)
 	GetProductRating(ctx context.Context, id model.ID) (float32, error)
 	ListProducts(ctx context.Context, offset int, limit int) ([]*model.Product, error)
+	// TopReviewedProducts returns IDs of the products with the most reviews.
+	TopReviewedProducts(ctx context.Context, limit int) ([]model.ID, error)
 
 	CreateProductReview(ctx context.Context, review *model.Review) (model.ID, error)
+	// CreateProductReviews inserts reviews in one transaction and sets their IDs.
+	// Reviews of products that don't exist are skipped and keep ID 0.
+	CreateProductReviews(ctx context.Context, reviews []*model.Review) error
 	UpdateProductReview(ctx context.Context, review *model.Review) error
+	// PatchProductReview updates only the given fields of the review, e.g. "Rating".
+	PatchProductReview(ctx context.Context, review *model.Review, fields []string) error
 	DeleteProductReview(ctx context.Context, reviewID model.ID) error
 	GetProductReview(ctx context.Context, reviewID model.ID) (*model.Review, error)
 	ListProductReviews(ctx context.Context, productID model.ID, offset int, limit int) ([]*model.Review, error)
+
+	ListReviewerReviews(ctx context.Context, firstName string, lastName string) ([]*model.Review, error)
+	EraseReviewerReviews(ctx context.Context, firstName string, lastName string, entry *model.ErasureLogEntry) ([]*model.Review, error)
+	AppendErasureLog(ctx context.Context, entry *model.ErasureLogEntry) error
 }
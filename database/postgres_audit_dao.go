@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lameaux/golang-product-reviews/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ AuditDAO = (*postgresAuditDAO)(nil)
+
+type postgresAuditDAO struct {
+	db *gorm.DB
+}
+
+func NewPostgresAuditDAO(db *gorm.DB) *postgresAuditDAO {
+	return &postgresAuditDAO{
+		db: db,
+	}
+}
+
+func (d *postgresAuditDAO) CreateAuditEvent(ctx context.Context, event *model.AuditEvent) error {
+	// redelivered and replayed messages are stored once
+	if err := d.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "stream_seq"}}, DoNothing: true}).
+		Create(event).Error; err != nil {
+		return fmt.Errorf("CreateAuditEvent: %w", err)
+	}
+
+	return nil
+}
+
+func (d *postgresAuditDAO) ListAuditEvents(ctx context.Context, filter *AuditFilter) ([]*model.AuditEvent, error) {
+	query := d.db.WithContext(ctx).Table(model.TableAuditEvents)
+
+	if filter.ProductID != 0 {
+		query = query.Where("product_id = ?", filter.ProductID)
+	}
+	if filter.ReviewID != 0 {
+		query = query.Where("review_id = ?", filter.ReviewID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("received_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("received_at < ?", filter.To)
+	}
+
+	var result []*model.AuditEvent
+
+	if err := query.
+		Order("received_at, id").
+		Offset(filter.Offset).
+		Limit(filter.Limit).
+		Scan(&result).Error; err != nil {
+		return nil, fmt.Errorf("ListAuditEvents: %w", err)
+	}
+
+	return result, nil
+}
@@ -2,10 +2,12 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/lameaux/golang-product-reviews/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var _ DAO = (*postgresDAO)(nil)
@@ -45,6 +47,16 @@ func (d *postgresDAO) UpdateProduct(ctx context.Context, product *model.Product)
 	})
 }
 
+func (d *postgresDAO) PatchProduct(ctx context.Context, product *model.Product, fields []string) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(product).Select(fields).Updates(product).Error; err != nil {
+			return fmt.Errorf("tx.Updates: %w", err)
+		}
+
+		return nil
+	})
+}
+
 func (d *postgresDAO) DeleteProduct(ctx context.Context, id model.ID) error {
 	product := &model.Product{ID: id}
 	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
@@ -110,6 +122,22 @@ func (d *postgresDAO) ListProducts(ctx context.Context, offset int, limit int) (
 	return result, nil
 }
 
+func (d *postgresDAO) TopReviewedProducts(ctx context.Context, limit int) ([]model.ID, error) {
+	var result []model.ID
+
+	if err := d.db.WithContext(ctx).
+		Table(model.TableReviews).
+		Select("product_id").
+		Group("product_id").
+		Order("count(*) desc").
+		Limit(limit).
+		Scan(&result).Error; err != nil {
+		return nil, fmt.Errorf("TopReviewedProducts: %w", err)
+	}
+
+	return result, nil
+}
+
 func (d *postgresDAO) CreateProductReview(ctx context.Context, review *model.Review) (model.ID, error) {
 	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(review).Error; err != nil {
@@ -125,6 +153,52 @@ func (d *postgresDAO) CreateProductReview(ctx context.Context, review *model.Rev
 	return review.ID, nil
 }
 
+func (d *postgresDAO) CreateProductReviews(ctx context.Context, reviews []*model.Review) error {
+	productIDs := make([]model.ID, 0, len(reviews))
+	for _, review := range reviews {
+		productIDs = append(productIDs, review.ProductID)
+	}
+
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// products can't be deleted until the reviews are inserted
+		var existing []model.ID
+		if err := tx.Model(&model.Product{}).
+			Clauses(clause.Locking{Strength: "SHARE"}).
+			Where("id IN ?", productIDs).
+			Pluck("id", &existing).Error; err != nil {
+			return fmt.Errorf("tx.Pluck products: %w", err)
+		}
+
+		exists := make(map[model.ID]bool, len(existing))
+		for _, id := range existing {
+			exists[id] = true
+		}
+
+		var insert []*model.Review
+		for _, review := range reviews {
+			if exists[review.ProductID] {
+				insert = append(insert, review)
+			}
+		}
+
+		if len(insert) == 0 {
+			return nil
+		}
+
+		if err := tx.Create(&insert).Error; err != nil {
+			return fmt.Errorf("tx.Create: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("CreateProductReviews: %w", err)
+	}
+
+	return nil
+}
+
 func (d *postgresDAO) UpdateProductReview(ctx context.Context, review *model.Review) error {
 	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Save(review).Error; err != nil {
@@ -135,6 +209,16 @@ func (d *postgresDAO) UpdateProductReview(ctx context.Context, review *model.Rev
 	})
 }
 
+func (d *postgresDAO) PatchProductReview(ctx context.Context, review *model.Review, fields []string) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(review).Select(fields).Updates(review).Error; err != nil {
+			return fmt.Errorf("tx.Updates: %w", err)
+		}
+
+		return nil
+	})
+}
+
 func (d *postgresDAO) DeleteProductReview(ctx context.Context, reviewID model.ID) error {
 	review := &model.Review{ID: reviewID}
 	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
@@ -177,3 +261,105 @@ func (d *postgresDAO) ListProductReviews(ctx context.Context, productID model.ID
 
 	return result, nil
 }
+
+func (d *postgresDAO) ListReviewerReviews(ctx context.Context, firstName string, lastName string) ([]*model.Review, error) {
+	var result []*model.Review
+
+	if err := d.db.WithContext(ctx).
+		Table(model.TableReviews).
+		Where("first_name = ? AND last_name = ?", firstName, lastName).
+		Order("id").
+		Scan(&result).Error; err != nil {
+		return nil, fmt.Errorf("ListReviewerReviews: %w", err)
+	}
+
+	return result, nil
+}
+
+func (d *postgresDAO) EraseReviewerReviews(
+	ctx context.Context,
+	firstName string,
+	lastName string,
+	entry *model.ErasureLogEntry,
+) ([]*model.Review, error) {
+	var reviews []*model.Review
+
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(model.TableReviews).
+			Where("first_name = ? AND last_name = ?", firstName, lastName).
+			Order("id").
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Find(&reviews).Error; err != nil {
+			return fmt.Errorf("tx.Find reviews: %w", err)
+		}
+
+		if len(reviews) > 0 {
+			ids := make([]model.ID, 0, len(reviews))
+			for _, review := range reviews {
+				ids = append(ids, review.ID)
+			}
+
+			switch entry.Mode {
+			case model.ErasureModeAnonymize:
+				if err := tx.Table(model.TableReviews).
+					Where("id IN ?", ids).
+					Updates(map[string]any{
+						"first_name": model.AnonymousName,
+						"last_name":  "",
+					}).Error; err != nil {
+					return fmt.Errorf("tx.Updates reviews: %w", err)
+				}
+			case model.ErasureModeDelete:
+				if err := tx.Where("id IN ?", ids).Delete(&model.Review{}).Error; err != nil {
+					return fmt.Errorf("tx.Delete reviews: %w", err)
+				}
+			default:
+				return fmt.Errorf("unsupported erasure mode: %s", entry.Mode)
+			}
+		}
+
+		entry.ReviewCount = len(reviews)
+
+		return appendErasureLog(tx, entry)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("EraseReviewerReviews: %w", err)
+	}
+
+	return reviews, nil
+}
+
+func (d *postgresDAO) AppendErasureLog(ctx context.Context, entry *model.ErasureLogEntry) error {
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return appendErasureLog(tx, entry)
+	})
+
+	if err != nil {
+		return fmt.Errorf("AppendErasureLog: %w", err)
+	}
+
+	return nil
+}
+
+func appendErasureLog(tx *gorm.DB, entry *model.ErasureLogEntry) error {
+	// serialize writers, so every entry is chained to the latest one
+	if err := tx.Exec("LOCK TABLE " + model.TableErasureLog + " IN EXCLUSIVE MODE").Error; err != nil {
+		return fmt.Errorf("tx.Lock erasure log: %w", err)
+	}
+
+	var last model.ErasureLogEntry
+	err := tx.Order("id DESC").Take(&last).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("tx.Take erasure log: %w", err)
+	}
+
+	entry.PrevHash = last.Hash
+	entry.Hash = entry.ComputeHash()
+
+	if err := tx.Create(entry).Error; err != nil {
+		return fmt.Errorf("tx.Create erasure log: %w", err)
+	}
+
+	return nil
+}
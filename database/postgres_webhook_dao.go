@@ -0,0 +1,175 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/model"
+	"gorm.io/gorm"
+)
+
+var _ WebhookDAO = (*postgresWebhookDAO)(nil)
+
+type postgresWebhookDAO struct {
+	db *gorm.DB
+}
+
+func NewPostgresWebhookDAO(db *gorm.DB) *postgresWebhookDAO {
+	return &postgresWebhookDAO{
+		db: db,
+	}
+}
+
+func (d *postgresWebhookDAO) CreateWebhook(ctx context.Context, webhook *model.Webhook) (model.ID, error) {
+	if err := d.db.WithContext(ctx).Create(webhook).Error; err != nil {
+		return 0, fmt.Errorf("CreateWebhook: %w", err)
+	}
+
+	return webhook.ID, nil
+}
+
+func (d *postgresWebhookDAO) UpdateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	result := d.db.WithContext(ctx).
+		Model(webhook).
+		Select("url", "events", "secret", "paused").
+		Updates(webhook)
+	if result.Error != nil {
+		return fmt.Errorf("UpdateWebhook: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("UpdateWebhook: %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+func (d *postgresWebhookDAO) DeleteWebhook(ctx context.Context, id model.ID) error {
+	result := d.db.WithContext(ctx).Delete(&model.Webhook{ID: id})
+	if result.Error != nil {
+		return fmt.Errorf("DeleteWebhook: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("DeleteWebhook: %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+func (d *postgresWebhookDAO) GetWebhook(ctx context.Context, id model.ID) (*model.Webhook, error) {
+	var webhook model.Webhook
+
+	if err := d.db.WithContext(ctx).
+		Table(model.TableWebhooks).
+		Where("id = ?", id).
+		Take(&webhook).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("GetWebhook: %w", err)
+	}
+
+	return &webhook, nil
+}
+
+func (d *postgresWebhookDAO) ListWebhooks(ctx context.Context, offset int, limit int) ([]*model.Webhook, error) {
+	var result []*model.Webhook
+
+	if err := d.db.WithContext(ctx).
+		Table(model.TableWebhooks).
+		Order("id").
+		Offset(offset).
+		Limit(limit).
+		Scan(&result).Error; err != nil {
+		return nil, fmt.Errorf("ListWebhooks: %w", err)
+	}
+
+	return result, nil
+}
+
+func (d *postgresWebhookDAO) ListActiveWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	var result []*model.Webhook
+
+	if err := d.db.WithContext(ctx).
+		Table(model.TableWebhooks).
+		Where("paused = FALSE").
+		Scan(&result).Error; err != nil {
+		return nil, fmt.Errorf("ListActiveWebhooks: %w", err)
+	}
+
+	return result, nil
+}
+
+func (d *postgresWebhookDAO) CreateWebhookDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if err := d.db.WithContext(ctx).Create(deliveries).Error; err != nil {
+		return fmt.Errorf("CreateWebhookDeliveries: %w", err)
+	}
+
+	return nil
+}
+
+func (d *postgresWebhookDAO) ClaimDueWebhookDeliveries(
+	ctx context.Context,
+	now time.Time,
+	leaseUntil time.Time,
+	limit int,
+) ([]*model.WebhookDelivery, error) {
+	var result []*model.WebhookDelivery
+
+	if err := d.db.WithContext(ctx).Raw(`
+		UPDATE webhook_deliveries SET next_attempt_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT d.id FROM webhook_deliveries d
+			WHERE d.status = ? AND d.next_attempt_at <= ?
+			AND NOT EXISTS (SELECT 1 FROM webhooks w WHERE w.id = d.webhook_id AND w.paused)
+			ORDER BY d.next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		leaseUntil, now, model.DeliveryStatusPending, now, limit,
+	).Scan(&result).Error; err != nil {
+		return nil, fmt.Errorf("ClaimDueWebhookDeliveries: %w", err)
+	}
+
+	return result, nil
+}
+
+func (d *postgresWebhookDAO) UpdateWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	if err := d.db.WithContext(ctx).
+		Model(delivery).
+		Select("status", "attempts", "last_status_code", "last_error", "next_attempt_at", "updated_at").
+		Updates(delivery).Error; err != nil {
+		return fmt.Errorf("UpdateWebhookDelivery: %w", err)
+	}
+
+	return nil
+}
+
+func (d *postgresWebhookDAO) ListWebhookDeliveries(
+	ctx context.Context,
+	webhookID model.ID,
+	offset int,
+	limit int,
+) ([]*model.WebhookDelivery, error) {
+	var result []*model.WebhookDelivery
+
+	if err := d.db.WithContext(ctx).
+		Table(model.TableWebhookDeliveries).
+		Where("webhook_id = ?", webhookID).
+		Order("id DESC").
+		Offset(offset).
+		Limit(limit).
+		Scan(&result).Error; err != nil {
+		return nil, fmt.Errorf("ListWebhookDeliveries: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,29 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/model"
+)
+
+// ErrNotFound is returned by updates and deletes of webhooks that don't exist.
+var ErrNotFound = errors.New("not found")
+
+type WebhookDAO interface {
+	CreateWebhook(ctx context.Context, webhook *model.Webhook) (model.ID, error)
+	UpdateWebhook(ctx context.Context, webhook *model.Webhook) error
+	DeleteWebhook(ctx context.Context, id model.ID) error
+	GetWebhook(ctx context.Context, id model.ID) (*model.Webhook, error)
+	ListWebhooks(ctx context.Context, offset int, limit int) ([]*model.Webhook, error)
+	ListActiveWebhooks(ctx context.Context) ([]*model.Webhook, error)
+
+	CreateWebhookDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error
+	// ClaimDueWebhookDeliveries returns pending deliveries due at now and postpones
+	// them until leaseUntil, so concurrent dispatchers do not pick the same delivery.
+	// Deliveries of paused webhooks stay pending and are due again once resumed.
+	ClaimDueWebhookDeliveries(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]*model.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+	ListWebhookDeliveries(ctx context.Context, webhookID model.ID, offset int, limit int) ([]*model.WebhookDelivery, error)
+}
@@ -0,0 +1,20 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/model"
+)
+
+type AuditEvent struct {
+	ID         model.ID        `json:"id"`
+	EventID    string          `json:"event_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Actor      string          `json:"actor"`
+	ProductID  model.ID        `json:"product_id"`
+	ReviewID   model.ID        `json:"review_id"`
+	Action     string          `json:"action"`
+	ReceivedAt time.Time       `json:"received_at"`
+	Payload    json.RawMessage `json:"payload"`
+}
@@ -0,0 +1,35 @@
+package dto
+
+import "github.com/lameaux/golang-product-reviews/model"
+
+type CacheEntry struct {
+	Key  string `json:"key"`
+	Kind string `json:"kind"`
+	// TTLMs is -1 for keys without expiry.
+	TTLMs int64 `json:"ttl_ms"`
+	// SoftTTLMs is set for values, it is negative for stale ones.
+	SoftTTLMs *int64 `json:"soft_ttl_ms,omitempty"`
+}
+
+type CacheEntries struct {
+	ProductID  model.ID     `json:"product_id"`
+	Generation int64        `json:"generation"`
+	Entries    []CacheEntry `json:"entries"`
+}
+
+type CacheFlush struct {
+	Deleted int `json:"deleted"`
+}
+
+type CacheWarmRequest struct {
+	// By orders products by review count or recent traffic, reviews by default.
+	By          string `json:"by" validate:"omitempty,oneof=reviews traffic"`
+	Limit       int    `json:"limit" validate:"gte=0,lte=10000"`
+	Concurrency int    `json:"concurrency" validate:"gte=0,lte=100"`
+}
+
+type CacheWarmResult struct {
+	By       string `json:"by"`
+	Products int    `json:"products"`
+	Failed   int    `json:"failed"`
+}
@@ -13,3 +13,9 @@ type ProductWithRating struct {
 	Product
 	Rating float32 `json:"rating"`
 }
+
+// ProductRating is sent to live feed clients after review changes.
+type ProductRating struct {
+	ProductID model.ID `json:"product_id"`
+	Rating    float32  `json:"rating"`
+}
@@ -9,3 +9,36 @@ type Review struct {
 	Review    string       `json:"review" validate:"required"`
 	Rating    model.Rating `json:"rating" validate:"required,gte=1,lte=5"`
 }
+
+// BatchReview is an item of a review batch. ProductID is taken from the path
+// for batches of one product.
+type BatchReview struct {
+	ProductID model.ID `json:"product_id"`
+	Review
+}
+
+type ReviewBatch struct {
+	Reviews []*BatchReview `json:"reviews"`
+}
+
+// Statuses of review batch items.
+const (
+	BatchItemCreated  = "created"
+	BatchItemInvalid  = "invalid"
+	BatchItemNotFound = "not_found"
+)
+
+// ReviewBatchItem is the outcome of a review batch item, in the order of the request.
+type ReviewBatchItem struct {
+	Index     int      `json:"index"`
+	Status    string   `json:"status"`
+	ProductID model.ID `json:"product_id,omitempty"`
+	ID        model.ID `json:"id,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+type ReviewBatchResult struct {
+	Created int                `json:"created"`
+	Failed  int                `json:"failed"`
+	Items   []*ReviewBatchItem `json:"items"`
+}
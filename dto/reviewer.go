@@ -0,0 +1,29 @@
+package dto
+
+import "github.com/lameaux/golang-product-reviews/model"
+
+type Reviewer struct {
+	FirstName string `json:"first_name" validate:"required"`
+	LastName  string `json:"last_name" validate:"required"`
+}
+
+type ReviewerReview struct {
+	ProductID model.ID `json:"product_id"`
+	Review
+}
+
+type ReviewerExport struct {
+	Reviewer
+	Reviews []*ReviewerReview `json:"reviews"`
+}
+
+type ErasureRequest struct {
+	Reviewer
+	Mode string `json:"mode" validate:"required,oneof=anonymize delete"`
+}
+
+type ErasureResult struct {
+	Mode    string `json:"mode"`
+	Reviews int    `json:"reviews"`
+	Hash    string `json:"hash"`
+}
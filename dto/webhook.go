@@ -0,0 +1,28 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/model"
+)
+
+type Webhook struct {
+	ID     model.ID `json:"id"`
+	URL    string   `json:"url" validate:"required,http_url"`
+	Events []string `json:"events" validate:"dive,event_filter"`
+	Secret string   `json:"secret,omitempty" validate:"required,min=16"`
+	Paused bool     `json:"paused"`
+}
+
+type WebhookDelivery struct {
+	ID             model.ID  `json:"id"`
+	WebhookID      model.ID  `json:"webhook_id"`
+	EventID        string    `json:"event_id"`
+	Status         string    `json:"status"`
+	Attempts       int       `json:"attempts"`
+	LastStatusCode int       `json:"last_status_code,omitempty"`
+	LastError      string    `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
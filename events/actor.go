@@ -0,0 +1,15 @@
+package events
+
+import "context"
+
+type actorKey struct{}
+
+// WithActor stores the identity of whoever causes the change.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
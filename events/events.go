@@ -0,0 +1,170 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/model"
+)
+
+// SchemaVersion is bumped on every incompatible change of Event.
+// Version 2 removed the reviewer names from review snapshots.
+const SchemaVersion = 2
+
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+	ActionErase  = "erase"
+)
+
+var ErrInvalidEvent = errors.New("invalid event")
+
+// Event is a change notification shared by producers and consumers.
+type Event struct {
+	SchemaVersion int            `json:"schema_version"`
+	ID            string         `json:"id"`
+	Time          time.Time      `json:"time"`
+	Actor         string         `json:"actor,omitempty"`
+	Action        string         `json:"action"`
+	ProductID     model.ID       `json:"product_id"`
+	ReviewID      model.ID       `json:"review_id,omitempty"`
+	Product       *ProductChange `json:"product,omitempty"`
+	Price         *PriceChange   `json:"price,omitempty"`
+	Review        *ReviewChange  `json:"review,omitempty"`
+}
+
+// ProductChange holds product snapshots, Before is empty on create and After is empty on delete.
+type ProductChange struct {
+	Before *Product `json:"before,omitempty"`
+	After  *Product `json:"after,omitempty"`
+}
+
+type Product struct {
+	ID          model.ID           `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Price       model.PriceInCents `json:"price"`
+}
+
+// PriceChange is set on product updates that change the price.
+type PriceChange struct {
+	Old model.PriceInCents `json:"old"`
+	New model.PriceInCents `json:"new"`
+}
+
+// ReviewChange holds review snapshots, Before is empty on create and After is empty on delete.
+type ReviewChange struct {
+	Before *Review `json:"before,omitempty"`
+	After  *Review `json:"after,omitempty"`
+}
+
+// Review is a snapshot without the reviewer, events are kept in the audit trail,
+// the stream and webhook deliveries where an erasure can't reach them.
+type Review struct {
+	ID        model.ID     `json:"id"`
+	ProductID model.ID     `json:"product_id"`
+	Review    string       `json:"review"`
+	Rating    model.Rating `json:"rating"`
+}
+
+func New(ctx context.Context, action string, productID model.ID, reviewID model.ID) *Event {
+	return &Event{
+		SchemaVersion: SchemaVersion,
+		ID:            newID(),
+		Time:          time.Now().UTC(),
+		Actor:         ActorFromContext(ctx),
+		Action:        action,
+		ProductID:     productID,
+		ReviewID:      reviewID,
+	}
+}
+
+func NewProduct(product *model.Product) *Product {
+	if product == nil {
+		return nil
+	}
+
+	return &Product{
+		ID:          product.ID,
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
+	}
+}
+
+func NewReview(review *model.Review) *Review {
+	if review == nil {
+		return nil
+	}
+
+	return &Review{
+		ID:        review.ID,
+		ProductID: review.ProductID,
+		Review:    review.Review,
+		Rating:    review.Rating,
+	}
+}
+
+func (e *Event) Validate() error {
+	if e.SchemaVersion < 1 || e.SchemaVersion > SchemaVersion {
+		return fmt.Errorf("%w: unsupported schema version %d", ErrInvalidEvent, e.SchemaVersion)
+	}
+
+	if e.ID == "" || e.Time.IsZero() {
+		return fmt.Errorf("%w: missing id or time", ErrInvalidEvent)
+	}
+
+	switch e.Action {
+	case ActionCreate, ActionUpdate, ActionDelete, ActionErase:
+	default:
+		return fmt.Errorf("%w: unknown action %q", ErrInvalidEvent, e.Action)
+	}
+
+	if e.ProductID <= 0 {
+		return fmt.Errorf("%w: missing product", ErrInvalidEvent)
+	}
+
+	return nil
+}
+
+func Encode(e *Event) ([]byte, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+
+	return data, nil
+}
+
+func Decode(data []byte) (*Event, error) {
+	var e Event
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEvent, err)
+	}
+
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
+
+// newID returns a random UUID v4.
+func newID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,108 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	event := New(WithActor(t.Context(), "support"), ActionUpdate, 1, 2)
+	event.Review = &ReviewChange{
+		Before: &Review{ID: 2, ProductID: 1, Review: `Say "hi"`, Rating: 5},
+		After:  &Review{ID: 2, ProductID: 1, Review: "Meh", Rating: 2},
+	}
+
+	data, err := Encode(event)
+	require.NoError(t, err)
+
+	decoded, err := Decode(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, event, decoded)
+	assert.Equal(t, SchemaVersion, decoded.SchemaVersion)
+	assert.Equal(t, "support", decoded.Actor)
+	assert.Len(t, decoded.ID, 36)
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "not json", data: `{"product":1`},
+		{name: "legacy message", data: `{"product":1,"review":2,"action":"create"}`},
+		{name: "future schema", data: `{"schema_version":99,"id":"x","time":"` + now + `","action":"create","product_id":1}`},
+		{name: "unknown action", data: `{"schema_version":1,"id":"x","time":"` + now + `","action":"rename","product_id":1}`},
+		{name: "missing product", data: `{"schema_version":1,"id":"x","time":"` + now + `","action":"create"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode([]byte(tt.data))
+			assert.ErrorIs(t, err, ErrInvalidEvent)
+		})
+	}
+}
+
+func TestSubject(t *testing.T) {
+	tests := []struct {
+		action    string
+		productID int
+		reviewID  int
+		want      string
+	}{
+		{action: ActionCreate, productID: 1, want: "products.1.created"},
+		{action: ActionUpdate, productID: 1, want: "products.1.updated"},
+		{action: ActionDelete, productID: 1, want: "products.1.deleted"},
+		{action: ActionCreate, productID: 1, reviewID: 2, want: "products.1.reviews.2.created"},
+		{action: ActionErase, productID: 1, reviewID: 2, want: "products.1.reviews.2.erased"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, New(t.Context(), tt.action, tt.productID, tt.reviewID).Subject())
+		})
+	}
+}
+
+func TestMatchFilter(t *testing.T) {
+	productUpdate := New(t.Context(), ActionUpdate, 1, 0)
+	reviewUpdate := New(t.Context(), ActionUpdate, 1, 2)
+
+	tests := []struct {
+		filter string
+		event  *Event
+		want   bool
+	}{
+		{filter: "update", event: productUpdate, want: true},
+		{filter: "update", event: reviewUpdate, want: true},
+		{filter: "create", event: reviewUpdate, want: false},
+		{filter: "products.*.updated", event: productUpdate, want: true},
+		{filter: "products.*.updated", event: reviewUpdate, want: false},
+		{filter: "products.1.>", event: reviewUpdate, want: true},
+		{filter: "products.2.>", event: reviewUpdate, want: false},
+		{filter: "products.*.reviews.*.updated", event: reviewUpdate, want: true},
+		{filter: "products.1.updated.>", event: productUpdate, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			assert.Equal(t, tt.want, MatchFilter(tt.filter, tt.event))
+		})
+	}
+}
+
+func TestValidFilter(t *testing.T) {
+	for _, filter := range []string{"create", "erase", "products.>", "products.*.reviews.*.deleted"} {
+		assert.True(t, ValidFilter(filter), filter)
+	}
+
+	for _, filter := range []string{"rename", "reviews", "products", "products..created", "products.>.created", "products.1*.created"} {
+		assert.False(t, ValidFilter(filter), filter)
+	}
+}
@@ -0,0 +1,89 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lameaux/golang-product-reviews/model"
+)
+
+// Subjects form a hierarchy, so consumers can filter with NATS wildcards:
+//
+//	products.<id>.created|updated|deleted
+//	products.<id>.reviews.<rid>.created|updated|deleted|erased
+const (
+	SubjectPrefix = "products"
+	SubjectAll    = SubjectPrefix + ".>"
+)
+
+var subjectVerbs = map[string]string{
+	ActionCreate: "created",
+	ActionUpdate: "updated",
+	ActionDelete: "deleted",
+	ActionErase:  "erased",
+}
+
+// Subject returns the subject the event is published to.
+func (e *Event) Subject() string {
+	if e.ReviewID == 0 {
+		return fmt.Sprintf("%s.%d.%s", SubjectPrefix, e.ProductID, subjectVerbs[e.Action])
+	}
+
+	return fmt.Sprintf("%s.%d.reviews.%d.%s", SubjectPrefix, e.ProductID, e.ReviewID, subjectVerbs[e.Action])
+}
+
+// ProductSubjects matches all events of one product, including its reviews.
+func ProductSubjects(productID model.ID) string {
+	return fmt.Sprintf("%s.%d.>", SubjectPrefix, productID)
+}
+
+// ValidSubjectPattern reports whether pattern is a subject with optional
+// wildcards: "*" matches one token and a trailing ">" matches the rest.
+func ValidSubjectPattern(pattern string) bool {
+	tokens := strings.Split(pattern, ".")
+	for i, token := range tokens {
+		if token == "" || (token == ">" && i != len(tokens)-1) {
+			return false
+		}
+		if strings.ContainsAny(token, "*>") && len(token) > 1 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// MatchSubject reports whether subject matches pattern, using NATS wildcard rules.
+func MatchSubject(pattern string, subject string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+
+	for i, token := range patternTokens {
+		if token == ">" {
+			return len(subjectTokens) > i
+		}
+		if i >= len(subjectTokens) || (token != "*" && token != subjectTokens[i]) {
+			return false
+		}
+	}
+
+	return len(patternTokens) == len(subjectTokens)
+}
+
+// ValidFilter reports whether filter is an action or a subject pattern.
+func ValidFilter(filter string) bool {
+	if _, ok := subjectVerbs[filter]; ok {
+		return true
+	}
+
+	return strings.HasPrefix(filter, SubjectPrefix+".") && ValidSubjectPattern(filter)
+}
+
+// MatchFilter reports whether the event matches an action or a subject pattern.
+func MatchFilter(filter string, e *Event) bool {
+	if filter == e.Action {
+		return true
+	}
+
+	return strings.Contains(filter, ".") && MatchSubject(filter, e.Subject())
+}
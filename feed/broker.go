@@ -0,0 +1,144 @@
+package feed
+
+import (
+	"sync"
+
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/lameaux/golang-product-reviews/model"
+)
+
+const (
+	DefaultBufferSize = 1000
+	subscriberBuffer  = 64
+)
+
+// Entry is an event with its position in the stream, used as the SSE event ID.
+type Entry struct {
+	ID    uint64
+	Event *events.Event
+}
+
+// Broker fans out live events to subscribers and keeps the most recent ones
+// in a bounded buffer, so clients can resume after a reconnect.
+type Broker struct {
+	mu     sync.Mutex
+	buffer []Entry
+	next   int // next write position in buffer
+	full   bool
+	lastID uint64
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+func NewBroker(bufferSize int) *Broker {
+	return &Broker{
+		buffer: make([]Entry, max(bufferSize, 1)),
+		subs:   make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscription receives entries for one product or, with product 0, for all products.
+// C is closed when the subscriber falls behind or the broker is closed.
+type Subscription struct {
+	C       <-chan Entry
+	Backlog []Entry
+
+	ch        chan Entry
+	productID model.ID
+}
+
+func (s *Subscription) matches(entry Entry) bool {
+	return s.productID == 0 || s.productID == entry.Event.ProductID
+}
+
+// Publish stores the entry and sends it to matching subscribers.
+// Entries must arrive in ID order, older or repeated IDs are ignored.
+func (b *Broker) Publish(entry Entry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed || entry.ID <= b.lastID {
+		return
+	}
+
+	b.lastID = entry.ID
+	b.buffer[b.next] = entry
+	b.next = (b.next + 1) % len(b.buffer)
+	if b.next == 0 {
+		b.full = true
+	}
+
+	for sub := range b.subs {
+		if !sub.matches(entry) {
+			continue
+		}
+
+		select {
+		case sub.ch <- entry:
+		default:
+			// a slow client is dropped, it resumes from the buffer on reconnect
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe registers a subscriber. Buffered entries after lastEventID
+// are returned in Backlog, a zero lastEventID means live entries only.
+func (b *Broker) Subscribe(productID model.ID, lastEventID uint64) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Entry, subscriberBuffer)
+	sub := &Subscription{C: ch, ch: ch, productID: productID}
+
+	if b.closed {
+		close(ch)
+		return sub
+	}
+
+	if lastEventID > 0 {
+		for _, entry := range b.buffered() {
+			if entry.ID > lastEventID && sub.matches(entry) {
+				sub.Backlog = append(sub.Backlog, entry)
+			}
+		}
+	}
+
+	b.subs[sub] = struct{}{}
+
+	return sub
+}
+
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remove(sub)
+}
+
+// Close disconnects all subscribers.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subs {
+		b.remove(sub)
+	}
+}
+
+func (b *Broker) remove(sub *Subscription) {
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// buffered returns entries in ID order.
+func (b *Broker) buffered() []Entry {
+	if !b.full {
+		return b.buffer[:b.next]
+	}
+
+	return append(append([]Entry(nil), b.buffer[b.next:]...), b.buffer[:b.next]...)
+}
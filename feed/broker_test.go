@@ -0,0 +1,84 @@
+package feed
+
+import (
+	"testing"
+
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func entry(t *testing.T, id uint64, productID int) Entry {
+	return Entry{ID: id, Event: events.New(t.Context(), events.ActionCreate, productID, int(id))}
+}
+
+func ids(entries []Entry) []uint64 {
+	result := make([]uint64, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, e.ID)
+	}
+	return result
+}
+
+func TestBroker_Subscribe(t *testing.T) {
+	b := NewBroker(10)
+
+	all := b.Subscribe(0, 0)
+	product := b.Subscribe(2, 0)
+
+	b.Publish(entry(t, 1, 1))
+	b.Publish(entry(t, 2, 2))
+	b.Publish(entry(t, 2, 2)) // repeated ID is ignored
+
+	assert.Equal(t, uint64(1), (<-all.C).ID)
+	assert.Equal(t, uint64(2), (<-all.C).ID)
+	assert.Equal(t, uint64(2), (<-product.C).ID)
+	assert.Empty(t, all.C)
+	assert.Empty(t, product.C)
+
+	b.Unsubscribe(all)
+	_, ok := <-all.C
+	assert.False(t, ok)
+}
+
+func TestBroker_Resume(t *testing.T) {
+	b := NewBroker(3)
+
+	for id := uint64(1); id <= 5; id++ {
+		b.Publish(entry(t, id, int(id%2)+1))
+	}
+
+	assert.Equal(t, []uint64{3, 4, 5}, ids(b.Subscribe(0, 1).Backlog))
+	assert.Equal(t, []uint64{4, 5}, ids(b.Subscribe(0, 3).Backlog))
+	assert.Equal(t, []uint64{4}, ids(b.Subscribe(1, 1).Backlog))
+	assert.Empty(t, b.Subscribe(0, 5).Backlog)
+	assert.Empty(t, b.Subscribe(0, 0).Backlog)
+}
+
+func TestBroker_SlowSubscriber(t *testing.T) {
+	b := NewBroker(10)
+	sub := b.Subscribe(0, 0)
+
+	for id := uint64(1); id <= subscriberBuffer+1; id++ {
+		b.Publish(entry(t, id, 1))
+	}
+
+	var received int
+	for range sub.C {
+		received++
+	}
+	require.Equal(t, subscriberBuffer, received)
+}
+
+func TestBroker_Close(t *testing.T) {
+	b := NewBroker(10)
+	sub := b.Subscribe(0, 0)
+
+	b.Close()
+
+	_, ok := <-sub.C
+	assert.False(t, ok)
+
+	_, ok = <-b.Subscribe(0, 0).C
+	assert.False(t, ok)
+}
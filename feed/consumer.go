@@ -0,0 +1,64 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/lameaux/golang-product-reviews/notifier"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+)
+
+// Consume feeds the broker from the reviews stream until ctx is done.
+// Stream sequences are used as event IDs, so they are the same on every
+// API replica. The broker buffer is prefilled with the latest messages.
+func Consume(ctx context.Context, logger *zerolog.Logger, js jetstream.JetStream, broker *Broker) error {
+	stream, err := js.Stream(ctx, notifier.StreamName)
+	if err != nil {
+		return fmt.Errorf("Stream: %w", err)
+	}
+
+	info, err := stream.Info(ctx)
+	if err != nil {
+		return fmt.Errorf("Info: %w", err)
+	}
+
+	startSeq := uint64(1)
+	if bufferSize := uint64(len(broker.buffer)); info.State.LastSeq > bufferSize {
+		startSeq = info.State.LastSeq - bufferSize + 1
+	}
+
+	cons, err := stream.OrderedConsumer(ctx, jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{events.SubjectAll},
+		DeliverPolicy:  jetstream.DeliverByStartSequencePolicy,
+		OptStartSeq:    startSeq,
+	})
+	if err != nil {
+		return fmt.Errorf("OrderedConsumer: %w", err)
+	}
+
+	cc, err := cons.Consume(func(msg jetstream.Msg) {
+		meta, err := msg.Metadata()
+		if err != nil {
+			logger.Warn().Err(err).Msg("feed: invalid message metadata")
+			return
+		}
+
+		event, err := events.Decode(msg.Data())
+		if err != nil {
+			logger.Warn().Err(err).Uint64("seq", meta.Sequence.Stream).Msg("feed: invalid event")
+			return
+		}
+
+		broker.Publish(Entry{ID: meta.Sequence.Stream, Event: event})
+	})
+	if err != nil {
+		return fmt.Errorf("Consume: %w", err)
+	}
+	defer cc.Stop()
+
+	<-ctx.Done()
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package lock
+
+import (
+	"context"
+	"sync"
+
+	"github.com/lameaux/golang-product-reviews/model"
+)
+
+var _ Lock = (*LocalLock)(nil)
+
+// LocalLock is an in-process lock. Waiters are woken up on release instead
+// of polling. It serves a single replica, or is layered in front of a
+// distributed lock, so only one caller per replica waits for the latter.
+type LocalLock struct {
+	mu sync.Mutex
+	// held maps locked ids to channels closed on release
+	held map[model.ID]chan struct{}
+}
+
+func NewLocal() *LocalLock {
+	return &LocalLock{held: make(map[model.ID]chan struct{})}
+}
+
+func (l *LocalLock) Lock(ctx context.Context, id model.ID) (Lease, error) {
+	for {
+		l.mu.Lock()
+		released, busy := l.held[id]
+		if !busy {
+			released = make(chan struct{})
+			l.held[id] = released
+			l.mu.Unlock()
+
+			return &localLease{lock: l, id: id, released: released}, nil
+		}
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-released:
+		}
+	}
+}
+
+type localLease struct {
+	lock     *LocalLock
+	id       model.ID
+	released chan struct{}
+	once     sync.Once
+}
+
+// Token returns 0, a local lease can't be lost, so it isn't fenced.
+// Counters of one process would also not be comparable with those of other replicas.
+func (l *localLease) Token() uint64 {
+	return 0
+}
+
+// Lost returns nil, a local lease can't be lost.
+func (l *localLease) Lost() <-chan struct{} {
+	return nil
+}
+
+func (l *localLease) Release(context.Context) error {
+	l.once.Do(func() {
+		l.lock.mu.Lock()
+		delete(l.lock.held, l.id)
+		l.lock.mu.Unlock()
+
+		close(l.released)
+	})
+
+	return nil
+}
+
+var _ Lock = (*LayeredLock)(nil)
+
+// LayeredLock takes the local lock before the remote one.
+// The lease has the fencing token and the renewal of the remote lock.
+type LayeredLock struct {
+	local  Lock
+	remote Lock
+}
+
+func NewLayered(local Lock, remote Lock) *LayeredLock {
+	return &LayeredLock{local: local, remote: remote}
+}
+
+func (l *LayeredLock) Lock(ctx context.Context, id model.ID) (Lease, error) {
+	local, err := l.local.Lock(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	remote, err := l.remote.Lock(ctx, id)
+	if err != nil {
+		_ = local.Release(ctx)
+		return nil, err
+	}
+
+	return &layeredLease{local: local, remote: remote}, nil
+}
+
+type layeredLease struct {
+	local  Lease
+	remote Lease
+}
+
+func (l *layeredLease) Token() uint64 {
+	return l.remote.Token()
+}
+
+func (l *layeredLease) Lost() <-chan struct{} {
+	return l.remote.Lost()
+}
+
+func (l *layeredLease) Release(ctx context.Context) error {
+	err := l.remote.Release(ctx)
+	_ = l.local.Release(ctx)
+	return err
+}
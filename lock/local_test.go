@@ -0,0 +1,81 @@
+package lock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalLock(t *testing.T) {
+	l := NewLocal()
+
+	first, err := l.Lock(t.Context(), 1)
+	require.NoError(t, err)
+
+	// other ids are not blocked
+	other, err := l.Lock(t.Context(), 2)
+	require.NoError(t, err)
+	require.NoError(t, other.Release(t.Context()))
+
+	acquired := make(chan Lease)
+	go func() {
+		lease, err := l.Lock(context.Background(), 1)
+		assert.NoError(t, err)
+		acquired <- lease
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("lock acquired while held")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	require.NoError(t, first.Release(t.Context()))
+	// releasing twice is a no-op
+	require.NoError(t, first.Release(t.Context()))
+
+	select {
+	case second := <-acquired:
+		assert.Zero(t, second.Token())
+		require.NoError(t, second.Release(t.Context()))
+	case <-time.After(time.Second):
+		t.Fatal("lock not acquired after release")
+	}
+}
+
+func TestLocalLock_Canceled(t *testing.T) {
+	l := NewLocal()
+
+	lease, err := l.Lock(t.Context(), 1)
+	require.NoError(t, err)
+	defer lease.Release(t.Context())
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = l.Lock(ctx, 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// failingLock never grants the lock.
+type failingLock struct{}
+
+func (failingLock) Lock(context.Context, model.ID) (Lease, error) {
+	return nil, ErrLocked
+}
+
+func TestLayeredLock(t *testing.T) {
+	local := NewLocal()
+
+	_, err := NewLayered(local, failingLock{}).Lock(t.Context(), 1)
+	assert.ErrorIs(t, err, ErrLocked)
+
+	// the local lock is released when the remote one fails
+	lease, err := NewLayered(local, NewLocal()).Lock(t.Context(), 1)
+	require.NoError(t, err)
+	require.NoError(t, lease.Release(t.Context()))
+}
@@ -10,6 +10,18 @@ import (
 var ErrLocked = errors.New("locked")
 
 type Lock interface {
-	Lock(ctx context.Context, id model.ID) error
-	Unlock(ctx context.Context, id model.ID) error
+	// Lock acquires the lock of id, it is held until the lease is released.
+	Lock(ctx context.Context, id model.ID) (Lease, error)
+}
+
+// Lease is a held lock. It is renewed in the background until released,
+// Lost is closed if renewal fails and the lock may be held by someone else.
+type Lease interface {
+	// Token is a fencing token, it grows with every acquisition of the lock,
+	// so a store can reject writes of a holder that lost it. It is 0 for
+	// leases that can't be lost, their writes need no fencing.
+	Token() uint64
+	Lost() <-chan struct{}
+	// Release stops renewal and frees the lock if it is still held by this lease.
+	Release(ctx context.Context) error
 }
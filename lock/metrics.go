@@ -0,0 +1,31 @@
+package lock
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	waitDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "lock_wait_seconds",
+		Help:    "Time spent acquiring locks by backend and result.",
+		Buckets: []float64{.001, .005, .01, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"backend", "result"})
+
+	contention = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "lock_contention_total",
+		Help: "Number of attempts that found the lock held, by backend.",
+	}, []string{"backend"})
+)
+
+const (
+	resultAcquired = "acquired"
+	resultLocked   = "locked"
+	resultError    = "error"
+)
+
+func observeWait(backend string, result string, start time.Time) {
+	waitDuration.WithLabelValues(backend, result).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,286 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/rs/zerolog"
+)
+
+var _ Lock = (*PostgresLock)(nil)
+
+// lockNamespace is hashed into the advisory lock keys, so they don't collide
+// with advisory locks of other applications on the same database.
+const lockNamespace = "products:locks"
+
+// DefaultKeepAlive is the interval of pings that detect a lost connection.
+const DefaultKeepAlive = 5 * time.Second
+
+var errSessionDropped = errors.New("session dropped")
+
+// PostgresLock uses session advisory locks on one dedicated connection.
+// Postgres releases them when the session ends, so if the connection is lost
+// all leases are lost, and the next Lock opens a new connection.
+//
+// Advisory locks are reentrant within a session, so locks held by this
+// instance are tracked in-process and reported busy.
+type PostgresLock struct {
+	logger    *zerolog.Logger
+	db        *sql.DB
+	retry     Retry
+	keepAlive time.Duration
+
+	// mu guards the session and its held leases, never a database round trip
+	mu      sync.Mutex
+	session *pgSession
+}
+
+// pgSession is a dedicated connection with the leases held on it.
+type pgSession struct {
+	conn *sql.Conn
+	// busy is taken by queries, the connection runs one at a time
+	busy chan struct{}
+	held map[model.ID]*postgresLease
+	done chan struct{}
+}
+
+func NewPostgres(logger *zerolog.Logger, db *sql.DB, retry Retry, keepAlive time.Duration) *PostgresLock {
+	return &PostgresLock{logger: logger, db: db, retry: retry, keepAlive: keepAlive}
+}
+
+// advisoryKey is the 64-bit advisory lock key of id.
+func advisoryKey(id model.ID) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(lockNamespace))
+	_ = binary.Write(h, binary.BigEndian, int64(id))
+
+	return int64(h.Sum64())
+}
+
+func (l *PostgresLock) Lock(ctx context.Context, id model.ID) (Lease, error) {
+	return acquire(ctx, "postgres", l.retry, func(ctx context.Context) (Lease, error) {
+		lease, err := l.tryLock(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("lock: %w", err)
+		}
+
+		if lease == nil {
+			return nil, nil
+		}
+
+		l.logger.Debug().Int("id", id).Uint64("fence", lease.fence).Msg("postgres lock")
+		return lease, nil
+	})
+}
+
+// tryLock returns a nil lease if the lock is held.
+func (l *PostgresLock) tryLock(ctx context.Context, id model.ID) (*postgresLease, error) {
+	session, err := l.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the id is reserved while locking, so callers of this instance don't take it twice.
+	// A session dropped meanwhile has no held map, the next attempt connects again.
+	l.mu.Lock()
+	if _, busy := session.held[id]; busy || session.held == nil {
+		l.mu.Unlock()
+		return nil, nil
+	}
+	lease := &postgresLease{lock: l, session: session, id: id, lost: make(chan struct{})}
+	session.held[id] = lease
+	l.mu.Unlock()
+
+	// the fence is drawn on every attempt, tokens still grow with every acquisition
+	var locked bool
+	err = session.query(ctx, func(conn *sql.Conn) error {
+		return conn.QueryRowContext(ctx,
+			"SELECT pg_try_advisory_lock($1), nextval('lock_fences')", advisoryKey(id),
+		).Scan(&locked, &lease.fence)
+	})
+
+	if err != nil || !locked {
+		l.mu.Lock()
+		if session.held[id] == lease {
+			delete(session.held, id)
+		}
+		if err != nil && ctx.Err() == nil {
+			l.drop(session, err)
+		}
+		l.mu.Unlock()
+
+		return nil, err
+	}
+
+	return lease, nil
+}
+
+// connect returns the current session or opens one.
+func (l *PostgresLock) connect(ctx context.Context) (*pgSession, error) {
+	l.mu.Lock()
+	session := l.session
+	l.mu.Unlock()
+
+	if session != nil {
+		return session, nil
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// another caller connected meanwhile
+	if l.session != nil {
+		_ = conn.Close()
+		return l.session, nil
+	}
+
+	l.session = &pgSession{
+		conn: conn,
+		busy: make(chan struct{}, 1),
+		held: make(map[model.ID]*postgresLease),
+		done: make(chan struct{}),
+	}
+	go l.ping(l.session)
+
+	return l.session, nil
+}
+
+// query runs fn on the connection once it is free, waiting no longer than ctx.
+func (s *pgSession) query(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	select {
+	case s.busy <- struct{}{}:
+	case <-s.done:
+		return errSessionDropped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-s.busy }()
+
+	return fn(s.conn)
+}
+
+// ping detects a lost connection while locks are held.
+func (l *PostgresLock) ping(session *pgSession) {
+	ticker := time.NewTicker(l.keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-session.done:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), l.keepAlive)
+		err := session.query(ctx, func(conn *sql.Conn) error {
+			return conn.PingContext(ctx)
+		})
+		cancel()
+
+		if errors.Is(err, errSessionDropped) {
+			return
+		}
+		if err != nil {
+			l.mu.Lock()
+			l.drop(session, err)
+			l.mu.Unlock()
+			return
+		}
+	}
+}
+
+// drop discards the session, its locks are released by Postgres. l.mu is held.
+func (l *PostgresLock) drop(session *pgSession, cause error) {
+	if l.session != session {
+		return
+	}
+	l.session = nil
+
+	l.logger.Warn().Err(cause).Int("leases", len(session.held)).Msg("postgres lock connection dropped")
+
+	for _, lease := range session.held {
+		close(lease.lost)
+	}
+	session.held = nil
+
+	close(session.done)
+	discard(session.conn)
+}
+
+// discard closes the connection instead of returning it to the pool,
+// where it would keep session locks.
+func discard(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	_ = conn.Close()
+}
+
+// Close releases all locks and the connection.
+func (l *PostgresLock) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.session != nil {
+		l.drop(l.session, errors.New("closed"))
+	}
+}
+
+type postgresLease struct {
+	lock    *PostgresLock
+	session *pgSession
+	id      model.ID
+	fence   uint64
+	lost    chan struct{}
+}
+
+func (l *postgresLease) Token() uint64 {
+	return l.fence
+}
+
+func (l *postgresLease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+func (l *postgresLease) Release(ctx context.Context) error {
+	lock := l.lock
+
+	// released already, or with the lost connection. The id is free again right away,
+	// a new lease of this session takes the reentrant lock before or after the unlock.
+	lock.mu.Lock()
+	if l.session.held[l.id] != l {
+		lock.mu.Unlock()
+		return nil
+	}
+	delete(l.session.held, l.id)
+	lock.mu.Unlock()
+
+	var unlocked bool
+	err := l.session.query(ctx, func(conn *sql.Conn) error {
+		return conn.QueryRowContext(ctx, "SELECT pg_advisory_unlock($1)", advisoryKey(l.id)).Scan(&unlocked)
+	})
+	if errors.Is(err, errSessionDropped) {
+		return nil
+	}
+	if err != nil {
+		// the lock would stay held by the session
+		lock.mu.Lock()
+		lock.drop(l.session, err)
+		lock.mu.Unlock()
+		return fmt.Errorf("unlock: %w", err)
+	}
+
+	lock.logger.Debug().Int("id", l.id).Msg("postgres unlock")
+	return nil
+}
@@ -0,0 +1,43 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdvisoryKey(t *testing.T) {
+	assert.Equal(t, advisoryKey(1), advisoryKey(1))
+
+	// ids past 32 bits don't wrap onto the key of a smaller id
+	assert.NotEqual(t, advisoryKey(1), advisoryKey(1<<32+1))
+	assert.NotEqual(t, advisoryKey(0), advisoryKey(1<<32))
+}
+
+func TestPgSession_Query(t *testing.T) {
+	session := &pgSession{busy: make(chan struct{}, 1), done: make(chan struct{})}
+
+	// a query in progress holds the connection
+	session.busy <- struct{}{}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+
+	called := false
+	err := session.query(ctx, func(*sql.Conn) error {
+		called = true
+		return nil
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, called)
+
+	close(session.done)
+	err = session.query(t.Context(), func(*sql.Conn) error { return nil })
+	require.ErrorIs(t, err, errSessionDropped)
+
+	<-session.busy
+}
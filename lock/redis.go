@@ -2,7 +2,10 @@ package lock
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/lameaux/golang-product-reviews/model"
@@ -12,51 +15,177 @@ import (
 
 var _ Lock = (*RedisLock)(nil)
 
-const ttl = 10 * time.Second
+const defaultTTL = 10 * time.Second
 const prefix = "products:locks"
 
-const maxRetry = 5
+var (
+	// acquireScript sets the owner token and increments the fencing counter of the lock.
+	acquireScript = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return redis.call("INCR", KEYS[2])
+end
+return 0
+`)
+
+	// releaseScript deletes the lock only if it is still owned by the token.
+	releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+	// renewScript extends the lock only if it is still owned by the token.
+	renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+)
 
 type RedisLock struct {
 	logger *zerolog.Logger
 	client *redis.Client
+	retry  Retry
+	ttl    time.Duration
+	// renewEvery leaves time for two more attempts before the lock expires.
+	renewEvery time.Duration
+}
+
+func NewRedis(logger *zerolog.Logger, client *redis.Client, retry Retry) *RedisLock {
+	return &RedisLock{logger: logger, client: client, retry: retry, ttl: defaultTTL, renewEvery: defaultTTL / 3}
 }
 
-func NewRedis(logger *zerolog.Logger, client *redis.Client) *RedisLock {
-	return &RedisLock{logger: logger, client: client}
+func lockKey(id model.ID) string {
+	return fmt.Sprintf("%s:%d", prefix, id)
 }
 
-func (r *RedisLock) Lock(ctx context.Context, id model.ID) error {
-	key := fmt.Sprintf("%s:%d", prefix, id)
+// fenceKey holds the last fencing token of the lock, it has no TTL.
+func fenceKey(id model.ID) string {
+	return fmt.Sprintf("%s:%d:fence", prefix, id)
+}
+
+func (r *RedisLock) Lock(ctx context.Context, id model.ID) (Lease, error) {
+	key := lockKey(id)
+
+	owner, err := newOwnerToken()
+	if err != nil {
+		return nil, fmt.Errorf("lock: %w", err)
+	}
 
-	for i := 0; i < maxRetry; i++ {
-		ok, err := r.client.SetNX(ctx, key, "1", ttl).Result()
+	return acquire(ctx, "redis", r.retry, func(ctx context.Context) (Lease, error) {
+		fence, err := acquireScript.Run(ctx, r.client, []string{key, fenceKey(id)}, owner, r.ttl.Milliseconds()).Int64()
 		if err != nil {
-			return fmt.Errorf("lock: %w", err)
+			return nil, fmt.Errorf("lock: %w", err)
 		}
 
-		if ok {
-			r.logger.Debug().Str("key", key).Msg("redis lock")
-			return nil
+		if fence == 0 {
+			return nil, nil
 		}
 
+		r.logger.Debug().Str("key", key).Int64("fence", fence).Msg("redis lock")
+		return r.newLease(key, owner, uint64(fence)), nil
+	})
+}
+
+func newOwnerToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+type redisLease struct {
+	lock  *RedisLock
+	key   string
+	owner string
+	fence uint64
+
+	lost     chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	renewing sync.WaitGroup
+}
+
+func (r *RedisLock) newLease(key string, owner string, fence uint64) *redisLease {
+	l := &redisLease{
+		lock:  r,
+		key:   key,
+		owner: owner,
+		fence: fence,
+		lost:  make(chan struct{}),
+		stop:  make(chan struct{}),
+	}
+
+	l.renewing.Add(1)
+	go l.renew()
+
+	return l
+}
+
+func (l *redisLease) Token() uint64 {
+	return l.fence
+}
+
+func (l *redisLease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// renew extends the lock until released. A renewal that finds the lock
+// owned by someone else, or fails until the lock would have expired, loses it.
+func (l *redisLease) renew() {
+	defer l.renewing.Done()
+
+	ticker := time.NewTicker(l.lock.renewEvery)
+	defer ticker.Stop()
+
+	renewedAt := time.Now()
+	for {
 		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(time.Duration(i) * time.Second):
+		case <-l.stop:
+			return
+		case <-ticker.C:
 		}
-	}
 
-	return ErrLocked
+		ctx, cancel := context.WithTimeout(context.Background(), l.lock.renewEvery)
+		renewed, err := renewScript.Run(ctx, l.lock.client, []string{l.key}, l.owner, l.lock.ttl.Milliseconds()).Int64()
+		cancel()
+
+		switch {
+		case err == nil && renewed == 1:
+			renewedAt = time.Now()
+			continue
+		case err == nil:
+			l.lock.logger.Warn().Str("key", l.key).Msg("redis lock lost")
+		case time.Since(renewedAt) < l.lock.ttl:
+			l.lock.logger.Warn().Err(err).Str("key", l.key).Msg("redis lock renewal failed")
+			continue
+		default:
+			l.lock.logger.Warn().Err(err).Str("key", l.key).Msg("redis lock expired")
+		}
+
+		close(l.lost)
+		return
+	}
 }
 
-func (r *RedisLock) Unlock(ctx context.Context, id model.ID) error {
-	key := fmt.Sprintf("%s:%d", prefix, id)
+func (l *redisLease) Release(ctx context.Context) error {
+	l.stopOnce.Do(func() { close(l.stop) })
+	l.renewing.Wait()
 
-	if err := r.client.Del(ctx, key).Err(); err != nil {
+	released, err := releaseScript.Run(ctx, l.lock.client, []string{l.key}, l.owner).Int64()
+	if err != nil {
 		return fmt.Errorf("unlock: %w", err)
 	}
 
-	r.logger.Debug().Str("key", key).Msg("redis unlock")
+	if released == 0 {
+		l.lock.logger.Warn().Str("key", l.key).Msg("redis lock was not held on release")
+		return nil
+	}
+
+	l.lock.logger.Debug().Str("key", l.key).Msg("redis unlock")
 	return nil
 }
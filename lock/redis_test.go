@@ -0,0 +1,95 @@
+package lock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedisLock(t *testing.T) (*RedisLock, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	l := NewRedis(&log.Logger, client, Retry{Attempts: 1, Backoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	l.ttl = 300 * time.Millisecond
+	l.renewEvery = 20 * time.Millisecond
+
+	return l, mr
+}
+
+func TestRedisLock_Fence(t *testing.T) {
+	l, _ := newTestRedisLock(t)
+
+	first, err := l.Lock(t.Context(), 1)
+	require.NoError(t, err)
+
+	_, err = l.Lock(t.Context(), 1)
+	require.ErrorIs(t, err, ErrLocked)
+
+	require.NoError(t, first.Release(t.Context()))
+
+	second, err := l.Lock(t.Context(), 1)
+	require.NoError(t, err)
+	defer second.Release(t.Context())
+
+	assert.Greater(t, second.Token(), first.Token())
+}
+
+func TestRedisLock_ReleaseByNonOwner(t *testing.T) {
+	l, mr := newTestRedisLock(t)
+
+	lease, err := l.Lock(t.Context(), 1)
+	require.NoError(t, err)
+
+	// the lock expired and was taken over
+	mr.Set(lockKey(1), "other-owner")
+
+	require.NoError(t, lease.Release(t.Context()))
+
+	owner, err := mr.Get(lockKey(1))
+	require.NoError(t, err)
+	assert.Equal(t, "other-owner", owner)
+}
+
+func TestRedisLock_Renew(t *testing.T) {
+	l, mr := newTestRedisLock(t)
+
+	lease, err := l.Lock(t.Context(), 1)
+	require.NoError(t, err)
+	defer lease.Release(t.Context())
+
+	// past the TTL of the acquisition, renewals extend it each time
+	for range 3 {
+		mr.FastForward(200 * time.Millisecond)
+		require.Eventually(t, func() bool { return mr.TTL(lockKey(1)) > 200*time.Millisecond }, time.Second, time.Millisecond)
+	}
+
+	assert.True(t, mr.Exists(lockKey(1)))
+	select {
+	case <-lease.Lost():
+		t.Fatal("lease lost while renewed")
+	default:
+	}
+}
+
+func TestRedisLock_Lost(t *testing.T) {
+	l, mr := newTestRedisLock(t)
+
+	lease, err := l.Lock(t.Context(), 1)
+	require.NoError(t, err)
+	defer lease.Release(t.Context())
+
+	mr.Set(lockKey(1), "other-owner")
+
+	select {
+	case <-lease.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("lease not lost after the lock changed owner")
+	}
+}
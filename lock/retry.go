@@ -0,0 +1,95 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// Retry is how long Lock waits for a lock held by someone else.
+type Retry struct {
+	// Attempts to acquire the lock, the first one included.
+	Attempts int
+	// Backoff before the second attempt, it doubles up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Jitter is the fraction of the backoff that is randomized, 0 to 1,
+	// so waiters of the same lock don't retry in lockstep.
+	Jitter float64
+}
+
+func DefaultRetry() Retry {
+	return Retry{Attempts: 5, Backoff: 50 * time.Millisecond, MaxBackoff: time.Second, Jitter: 0.5}
+}
+
+func (r Retry) Validate() error {
+	if r.Attempts < 1 {
+		return fmt.Errorf("invalid attempts %d", r.Attempts)
+	}
+
+	if r.Backoff <= 0 || r.MaxBackoff < r.Backoff {
+		return fmt.Errorf("invalid backoff %s, max %s", r.Backoff, r.MaxBackoff)
+	}
+
+	if r.Jitter < 0 || r.Jitter > 1 {
+		return fmt.Errorf("invalid jitter %v", r.Jitter)
+	}
+
+	return nil
+}
+
+// delay before the attempt following the given one, counted from 0, without jitter.
+func (r Retry) delay(attempt int) time.Duration {
+	delay := r.Backoff
+	for range attempt {
+		delay *= 2
+		if delay >= r.MaxBackoff {
+			return r.MaxBackoff
+		}
+	}
+
+	return delay
+}
+
+// acquire calls try until it returns a lease, an error, or the attempts run out.
+// It gives up with ErrLocked early rather than wait past the deadline of ctx.
+func acquire(ctx context.Context, backend string, r Retry, try func(ctx context.Context) (Lease, error)) (Lease, error) {
+	start := time.Now()
+
+	for attempt := 0; ; attempt++ {
+		lease, err := try(ctx)
+		if err != nil {
+			observeWait(backend, resultError, start)
+			return nil, err
+		}
+
+		if lease != nil {
+			observeWait(backend, resultAcquired, start)
+			return lease, nil
+		}
+
+		contention.WithLabelValues(backend).Inc()
+
+		if attempt == r.Attempts-1 {
+			break
+		}
+
+		delay := r.delay(attempt)
+		delay -= time.Duration(r.Jitter * rand.Float64() * float64(delay))
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			observeWait(backend, resultError, start)
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	observeWait(backend, resultLocked, start)
+	return nil, ErrLocked
+}
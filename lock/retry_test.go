@@ -0,0 +1,83 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetry_Delay(t *testing.T) {
+	r := Retry{Attempts: 10, Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	assert.Equal(t, 100*time.Millisecond, r.delay(0))
+	assert.Equal(t, 200*time.Millisecond, r.delay(1))
+	assert.Equal(t, 800*time.Millisecond, r.delay(3))
+	assert.Equal(t, time.Second, r.delay(4))
+	assert.Equal(t, time.Second, r.delay(100))
+}
+
+func TestRetry_Validate(t *testing.T) {
+	assert.NoError(t, DefaultRetry().Validate())
+	assert.Error(t, Retry{Attempts: 0, Backoff: time.Second, MaxBackoff: time.Second}.Validate())
+	assert.Error(t, Retry{Attempts: 1, Backoff: time.Second, MaxBackoff: time.Millisecond}.Validate())
+	assert.Error(t, Retry{Attempts: 1, Backoff: time.Second, MaxBackoff: time.Second, Jitter: 1.5}.Validate())
+}
+
+func TestAcquire(t *testing.T) {
+	retry := Retry{Attempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond, Jitter: 1}
+
+	t.Run("acquired after contention", func(t *testing.T) {
+		tries := 0
+		lease, err := acquire(t.Context(), "test-acquired", retry, func(ctx context.Context) (Lease, error) {
+			tries++
+			if tries < 3 {
+				return nil, nil
+			}
+			return NewLocal().Lock(ctx, 1)
+		})
+		require.NoError(t, err)
+		require.NotNil(t, lease)
+
+		assert.Equal(t, 3, tries)
+		assert.Equal(t, 2.0, testutil.ToFloat64(contention.WithLabelValues("test-acquired")))
+	})
+
+	t.Run("attempts exhausted", func(t *testing.T) {
+		tries := 0
+		_, err := acquire(t.Context(), "test-locked", retry, func(context.Context) (Lease, error) {
+			tries++
+			return nil, nil
+		})
+		assert.ErrorIs(t, err, ErrLocked)
+		assert.Equal(t, 3, tries)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		want := errors.New("boom")
+		_, err := acquire(t.Context(), "test-error", retry, func(context.Context) (Lease, error) {
+			return nil, want
+		})
+		assert.ErrorIs(t, err, want)
+	})
+
+	t.Run("deadline before next attempt", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+
+		slow := Retry{Attempts: 5, Backoff: time.Second, MaxBackoff: time.Second}
+		start := time.Now()
+		tries := 0
+		_, err := acquire(ctx, "test-deadline", slow, func(context.Context) (Lease, error) {
+			tries++
+			return nil, nil
+		})
+		assert.ErrorIs(t, err, ErrLocked)
+		assert.Equal(t, 1, tries)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE erasure_log (
+    id SERIAL PRIMARY KEY,
+    requested_at TIMESTAMPTZ NOT NULL,
+    subject_hash VARCHAR(64) NOT NULL,
+    mode VARCHAR(16) NOT NULL,
+    review_count INT NOT NULL,
+    prev_hash VARCHAR(64) NOT NULL,
+    hash VARCHAR(64) NOT NULL UNIQUE
+);
+
+CREATE INDEX idx_reviews_reviewer ON reviews (first_name, last_name);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_reviews_reviewer;
+
+DROP TABLE erasure_log;
+-- +goose StatementEnd
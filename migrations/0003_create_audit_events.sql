@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    product_id INT NOT NULL,
+    review_id INT NOT NULL,
+    action VARCHAR(32) NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL,
+    payload JSONB NOT NULL
+);
+
+CREATE INDEX idx_audit_events_product_id ON audit_events (product_id, received_at);
+CREATE INDEX idx_audit_events_received_at ON audit_events (received_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE audit_events;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE audit_events ADD COLUMN stream_seq BIGINT;
+
+CREATE UNIQUE INDEX idx_audit_events_stream_seq ON audit_events (stream_seq);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_audit_events_stream_seq;
+
+ALTER TABLE audit_events DROP COLUMN stream_seq;
+-- +goose StatementEnd
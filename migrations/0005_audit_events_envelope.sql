@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE audit_events
+    ADD COLUMN event_id VARCHAR(36) NOT NULL DEFAULT '',
+    ADD COLUMN occurred_at TIMESTAMPTZ,
+    ADD COLUMN actor VARCHAR(256) NOT NULL DEFAULT '';
+
+UPDATE audit_events SET occurred_at = received_at;
+
+ALTER TABLE audit_events ALTER COLUMN occurred_at SET NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE audit_events
+    DROP COLUMN event_id,
+    DROP COLUMN occurred_at,
+    DROP COLUMN actor;
+-- +goose StatementEnd
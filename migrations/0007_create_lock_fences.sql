@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- fencing tokens of the Postgres advisory lock
+CREATE SEQUENCE lock_fences;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP SEQUENCE lock_fences;
+-- +goose StatementEnd
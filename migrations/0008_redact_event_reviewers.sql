@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- events of schema version 1 carried the reviewer names in review snapshots
+UPDATE audit_events
+SET payload = payload
+    #- '{review,before,first_name}' #- '{review,before,last_name}'
+    #- '{review,after,first_name}' #- '{review,after,last_name}'
+WHERE payload ? 'review';
+
+UPDATE webhook_deliveries
+SET payload = payload
+    #- '{review,before,first_name}' #- '{review,before,last_name}'
+    #- '{review,after,first_name}' #- '{review,after,last_name}'
+WHERE payload ? 'review';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- the names can't be restored
+SELECT 1;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+const TableAuditEvents = "audit_events"
+
+type AuditEvent struct {
+	ID         ID        `gorm:"primaryKey;column:id"`
+	StreamSeq  uint64    `gorm:"column:stream_seq"`
+	EventID    string    `gorm:"column:event_id"`
+	OccurredAt time.Time `gorm:"column:occurred_at"`
+	Actor      string    `gorm:"column:actor"`
+	ProductID  ID        `gorm:"column:product_id"`
+	ReviewID   ID        `gorm:"column:review_id"`
+	Action     string    `gorm:"column:action"`
+	ReceivedAt time.Time `gorm:"column:received_at"`
+	Payload    string    `gorm:"column:payload"`
+}
+
+func (AuditEvent) TableName() string {
+	return TableAuditEvents
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+const TableErasureLog = "erasure_log"
+
+const (
+	ErasureModeExport    = "export"
+	ErasureModeAnonymize = "anonymize"
+	ErasureModeDelete    = "delete"
+)
+
+const AnonymousName = "Anonymous"
+
+// ErasureLogEntry is an append-only record of a data-subject request.
+// Every entry is chained to the previous one by hash, so any modification
+// of the log breaks the chain.
+type ErasureLogEntry struct {
+	ID          ID        `gorm:"primaryKey;column:id"`
+	RequestedAt time.Time `gorm:"column:requested_at"`
+	SubjectHash string    `gorm:"column:subject_hash"`
+	Mode        string    `gorm:"column:mode"`
+	ReviewCount int       `gorm:"column:review_count"`
+	PrevHash    string    `gorm:"column:prev_hash"`
+	Hash        string    `gorm:"column:hash"`
+}
+
+func (ErasureLogEntry) TableName() string {
+	return TableErasureLog
+}
+
+func (e *ErasureLogEntry) ComputeHash() string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s|%s|%s|%d|%s",
+		e.RequestedAt.UTC().Format(time.RFC3339Nano),
+		e.SubjectHash,
+		e.Mode,
+		e.ReviewCount,
+		e.PrevHash,
+	))
+	return hex.EncodeToString(sum[:])
+}
+
+// SubjectHash identifies a reviewer in the erasure log without storing personal data.
+func SubjectHash(firstName string, lastName string) string {
+	sum := sha256.Sum256([]byte(firstName + "\x00" + lastName))
+	return hex.EncodeToString(sum[:])
+}
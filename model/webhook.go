@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+const (
+	TableWebhooks          = "webhooks"
+	TableWebhookDeliveries = "webhook_deliveries"
+)
+
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusDead      = "dead"
+)
+
+type Webhook struct {
+	ID        ID        `gorm:"primaryKey;column:id"`
+	URL       string    `gorm:"column:url"`
+	Events    string    `gorm:"column:events"` // comma-separated actions, empty means all
+	Secret    string    `gorm:"column:secret"`
+	Paused    bool      `gorm:"column:paused"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+func (Webhook) TableName() string {
+	return TableWebhooks
+}
+
+type WebhookDelivery struct {
+	ID             ID        `gorm:"primaryKey;column:id"`
+	WebhookID      ID        `gorm:"column:webhook_id"`
+	EventID        string    `gorm:"column:event_id"`
+	Payload        string    `gorm:"column:payload"`
+	Status         string    `gorm:"column:status"`
+	Attempts       int       `gorm:"column:attempts"`
+	LastStatusCode int       `gorm:"column:last_status_code"`
+	LastError      string    `gorm:"column:last_error"`
+	NextAttemptAt  time.Time `gorm:"column:next_attempt_at"`
+	CreatedAt      time.Time `gorm:"column:created_at"`
+	UpdatedAt      time.Time `gorm:"column:updated_at"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return TableWebhookDeliveries
+}
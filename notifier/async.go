@@ -0,0 +1,337 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/rs/zerolog"
+)
+
+// OverflowPolicy decides what happens to an event when the queue is full.
+type OverflowPolicy string
+
+const (
+	// OverflowBlock waits for space in the queue, or until the request is canceled.
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropOldest drops the oldest queued event to make space.
+	OverflowDropOldest OverflowPolicy = "drop-oldest"
+	// OverflowSpill appends the event to a file, it is queued again once there is space.
+	OverflowSpill OverflowPolicy = "spill"
+)
+
+const restoreInterval = time.Second
+
+var _ BatchNotifier = (*AsyncNotifier)(nil)
+
+type AsyncConfig struct {
+	// QueueSize is the number of queued events, a batch counts with all its events.
+	QueueSize int
+	Workers   int
+	Overflow  OverflowPolicy
+	SpillPath string
+}
+
+func DefaultAsyncConfig() AsyncConfig {
+	return AsyncConfig{
+		QueueSize: 1000,
+		Workers:   4,
+		Overflow:  OverflowDropOldest,
+		SpillPath: "notifier-spill.jsonl",
+	}
+}
+
+func (c AsyncConfig) Validate() error {
+	if c.QueueSize < 1 || c.Workers < 1 {
+		return errors.New("queue size and workers must be positive")
+	}
+
+	switch c.Overflow {
+	case OverflowBlock, OverflowDropOldest:
+	case OverflowSpill:
+		if c.SpillPath == "" {
+			return errors.New("spill path is required")
+		}
+	default:
+		return fmt.Errorf("unknown overflow policy: %q", c.Overflow)
+	}
+
+	return nil
+}
+
+// queuedEvents are events of one Notify or NotifyBatch call, they are sent together.
+type queuedEvents struct {
+	ctx    context.Context
+	events []*events.Event
+}
+
+// AsyncNotifier queues events and hands them to the next notifier from a
+// pool of workers, so callers don't wait for the broker. With more than
+// one worker events may be delivered out of order.
+//
+// The queue holds batches, its size is bounded by the events in them: events
+// are reserved before a batch is queued and freed once a worker takes it.
+type AsyncNotifier struct {
+	logger *zerolog.Logger
+	next   Notifier
+	cfg    AsyncConfig
+	queue  chan queuedEvents
+	spill  *spillFile
+
+	depthMu sync.Mutex
+	depth   int
+	// freed is closed and replaced when events leave the queue
+	freed chan struct{}
+
+	mu      sync.RWMutex
+	closed  bool
+	stop    chan struct{}
+	workers sync.WaitGroup
+	restore sync.WaitGroup
+}
+
+func NewAsync(logger *zerolog.Logger, next Notifier, cfg AsyncConfig) (*AsyncNotifier, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	n := &AsyncNotifier{
+		logger: logger,
+		next:   next,
+		cfg:    cfg,
+		queue:  make(chan queuedEvents, cfg.QueueSize),
+		freed:  make(chan struct{}),
+		stop:   make(chan struct{}),
+	}
+
+	if cfg.Overflow == OverflowSpill {
+		spill, err := openSpillFile(cfg.SpillPath)
+		if err != nil {
+			return nil, fmt.Errorf("openSpillFile: %w", err)
+		}
+		n.spill = spill
+		spilledEvents.Set(float64(spill.len()))
+
+		n.restore.Add(1)
+		go n.restoreLoop()
+	}
+
+	n.workers.Add(cfg.Workers)
+	for range cfg.Workers {
+		go n.work()
+	}
+
+	return n, nil
+}
+
+func (n *AsyncNotifier) Notify(ctx context.Context, event *events.Event) {
+	n.enqueue(ctx, []*events.Event{event})
+}
+
+// NotifyBatch queues the batch as one item, so it is sent together.
+func (n *AsyncNotifier) NotifyBatch(ctx context.Context, batch []*events.Event) {
+	if len(batch) == 0 {
+		return
+	}
+
+	n.enqueue(ctx, batch)
+}
+
+func (n *AsyncNotifier) enqueue(ctx context.Context, batch []*events.Event) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	if n.closed {
+		// late events during shutdown are sent directly
+		NotifyBatch(ctx, n.next, batch)
+		return
+	}
+
+	item := queuedEvents{ctx: context.WithoutCancel(ctx), events: batch}
+
+	reserved, freed := n.reserve(len(batch))
+	if reserved {
+		n.queue <- item
+		return
+	}
+
+	switch n.cfg.Overflow {
+	case OverflowBlock:
+		if !n.waitReserve(len(batch), ctx.Done()) {
+			droppedEvents.WithLabelValues("canceled").Add(float64(len(batch)))
+			n.logger.Error().Str("id", batch[0].ID).Int("events", len(batch)).Msg("notifier queue full, events dropped")
+			return
+		}
+		n.queue <- item
+	case OverflowDropOldest:
+		for !reserved {
+			select {
+			case dropped := <-n.queue:
+				n.free(len(dropped.events))
+				droppedEvents.WithLabelValues("overflow").Add(float64(len(dropped.events)))
+				n.logger.Warn().
+					Str("id", dropped.events[0].ID).
+					Int("events", len(dropped.events)).
+					Msg("notifier queue full, oldest events dropped")
+			case <-freed:
+			}
+			reserved, freed = n.reserve(len(batch))
+		}
+		n.queue <- item
+	case OverflowSpill:
+		for _, event := range batch {
+			if err := n.spill.append(event); err != nil {
+				droppedEvents.WithLabelValues("spill_failed").Inc()
+				n.logger.Error().Err(err).Str("id", event.ID).Msg("failed to spill event")
+				continue
+			}
+			spilledEvents.Inc()
+		}
+	}
+}
+
+// reserve makes space for size events, it fails with a channel closed once
+// events are freed. A batch larger than the queue fits into an empty one.
+// The queue channel has space for every reserved batch, sending never blocks.
+func (n *AsyncNotifier) reserve(size int) (bool, <-chan struct{}) {
+	n.depthMu.Lock()
+	defer n.depthMu.Unlock()
+
+	if n.depth > 0 && n.depth+size > n.cfg.QueueSize {
+		return false, n.freed
+	}
+
+	n.depth += size
+	queueDepth.Add(float64(size))
+	return true, nil
+}
+
+// waitReserve reserves space for size events, waiting for it until done is closed.
+func (n *AsyncNotifier) waitReserve(size int, done <-chan struct{}) bool {
+	for {
+		reserved, freed := n.reserve(size)
+		if reserved {
+			return true
+		}
+
+		select {
+		case <-freed:
+		case <-done:
+			return false
+		}
+	}
+}
+
+// free returns the space of size events taken from the queue.
+func (n *AsyncNotifier) free(size int) {
+	n.depthMu.Lock()
+	defer n.depthMu.Unlock()
+
+	n.depth -= size
+	queueDepth.Sub(float64(size))
+	close(n.freed)
+	n.freed = make(chan struct{})
+}
+
+// queued returns the number of queued events.
+func (n *AsyncNotifier) queued() int {
+	n.depthMu.Lock()
+	defer n.depthMu.Unlock()
+
+	return n.depth
+}
+
+// Close stops accepting events into the queue and waits until queued events
+// are sent or ctx is done. Spilled events stay on disk for the next start.
+func (n *AsyncNotifier) Close(ctx context.Context) error {
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return nil
+	}
+	n.closed = true
+	close(n.stop)
+	n.mu.Unlock()
+
+	n.restore.Wait()
+	close(n.queue)
+
+	done := make(chan struct{})
+	go func() {
+		n.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("flush: %w, %d events not sent", ctx.Err(), n.queued())
+	}
+
+	if n.spill != nil {
+		if err := n.spill.close(); err != nil {
+			return fmt.Errorf("close spill file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (n *AsyncNotifier) work() {
+	defer n.workers.Done()
+
+	for item := range n.queue {
+		n.free(len(item.events))
+		NotifyBatch(item.ctx, n.next, item.events)
+	}
+}
+
+// restoreLoop moves spilled events back to the queue once it is half empty.
+func (n *AsyncNotifier) restoreLoop() {
+	defer n.restore.Done()
+
+	ticker := time.NewTicker(restoreInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.stop:
+			return
+		case <-ticker.C:
+		}
+
+		if n.spill.len() == 0 || n.queued() > n.cfg.QueueSize/2 {
+			continue
+		}
+
+		spilled, err := n.spill.drain()
+		if err != nil {
+			n.logger.Error().Err(err).Msg("failed to read spilled events")
+			continue
+		}
+		spilledEvents.Set(0)
+
+		for i, event := range spilled {
+			if n.waitReserve(1, n.stop) {
+				n.queue <- queuedEvents{ctx: context.Background(), events: []*events.Event{event}}
+				continue
+			}
+
+			// keep the rest for the next start
+			for _, event := range spilled[i:] {
+				if err := n.spill.append(event); err != nil {
+					droppedEvents.WithLabelValues("spill_failed").Inc()
+					n.logger.Error().Err(err).Str("id", event.ID).Msg("failed to spill event")
+					continue
+				}
+				spilledEvents.Inc()
+			}
+			return
+		}
+
+		n.logger.Info().Int("events", len(spilled)).Msg("spilled events restored")
+	}
+}
@@ -0,0 +1,30 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/lameaux/golang-product-reviews/events"
+)
+
+var _ BatchNotifier = (*FanOut)(nil)
+
+// FanOut sends every event to all notifiers in order.
+type FanOut struct {
+	notifiers []Notifier
+}
+
+func NewFanOut(notifiers ...Notifier) *FanOut {
+	return &FanOut{notifiers: notifiers}
+}
+
+func (f *FanOut) Notify(ctx context.Context, event *events.Event) {
+	for _, n := range f.notifiers {
+		n.Notify(ctx, event)
+	}
+}
+
+func (f *FanOut) NotifyBatch(ctx context.Context, batch []*events.Event) {
+	for _, n := range f.notifiers {
+		NotifyBatch(ctx, n, batch)
+	}
+}
@@ -0,0 +1,51 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/rs/zerolog"
+)
+
+var _ Notifier = (*FileNotifier)(nil)
+
+// FileNotifier appends events to a file, one JSON document per line.
+type FileNotifier struct {
+	logger *zerolog.Logger
+	mu     sync.Mutex
+	file   *os.File
+}
+
+func NewFile(logger *zerolog.Logger, path string) (*FileNotifier, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+
+	return &FileNotifier{logger: logger, file: file}, nil
+}
+
+func (n *FileNotifier) Notify(_ context.Context, event *events.Event) {
+	msg, err := events.Encode(event)
+	if err != nil {
+		n.logger.Error().Err(err).Str("id", event.ID).Msg("failed to encode event")
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, err := n.file.Write(append(msg, '\n')); err != nil {
+		n.logger.Error().Err(err).Str("id", event.ID).Msg("failed to write event to file")
+	}
+}
+
+func (n *FileNotifier) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.file.Close()
+}
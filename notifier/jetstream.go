@@ -0,0 +1,32 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const StreamName = "REVIEWS"
+
+const DefaultStreamMaxAge = 30 * 24 * time.Hour
+
+// EnsureStream creates the reviews stream or updates its configuration.
+// The stream captures all product and review subjects, messages are kept
+// until they are older than maxAge, regardless of consumers.
+func EnsureStream(ctx context.Context, js jetstream.JetStream, maxAge time.Duration) (jetstream.Stream, error) {
+	stream, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      StreamName,
+		Subjects:  []string{events.SubjectAll},
+		Retention: jetstream.LimitsPolicy,
+		MaxAge:    maxAge,
+		Storage:   jetstream.FileStorage,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("CreateOrUpdateStream: %w", err)
+	}
+
+	return stream, nil
+}
@@ -0,0 +1,29 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/rs/zerolog"
+)
+
+var _ Notifier = (*LogNotifier)(nil)
+
+// LogNotifier only writes events to the log.
+type LogNotifier struct {
+	logger *zerolog.Logger
+}
+
+func NewLog(logger *zerolog.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Notify(_ context.Context, event *events.Event) {
+	msg, err := events.Encode(event)
+	if err != nil {
+		n.logger.Error().Err(err).Str("id", event.ID).Msg("failed to encode event")
+		return
+	}
+
+	n.logger.Info().RawJSON("event", msg).Msg("notify")
+}
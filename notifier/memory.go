@@ -0,0 +1,34 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+
+	"github.com/lameaux/golang-product-reviews/events"
+)
+
+var _ Notifier = (*MemoryNotifier)(nil)
+
+// MemoryNotifier keeps events in memory, it is meant for tests.
+type MemoryNotifier struct {
+	mu     sync.Mutex
+	events []*events.Event
+}
+
+func NewMemory() *MemoryNotifier {
+	return &MemoryNotifier{}
+}
+
+func (n *MemoryNotifier) Notify(_ context.Context, event *events.Event) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.events = append(n.events, event)
+}
+
+func (n *MemoryNotifier) Events() []*events.Event {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return append([]*events.Event(nil), n.events...)
+}
@@ -0,0 +1,23 @@
+package notifier
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	queueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "notifier_queue_depth",
+		Help: "Number of events waiting in the notifier queue.",
+	})
+
+	droppedEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "notifier_events_dropped_total",
+		Help: "Number of events dropped by the notifier, by reason.",
+	}, []string{"reason"})
+
+	spilledEvents = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "notifier_events_spilled",
+		Help: "Number of events waiting in the notifier spill file.",
+	})
+)
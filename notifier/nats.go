@@ -0,0 +1,85 @@
+package notifier
+
+import (
+	"context"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+)
+
+const publishTimeout = 5 * time.Second
+
+var _ BatchNotifier = (*NATSNotifier)(nil)
+
+// NATSNotifier publishes events to the JetStream stream, on the event subject.
+type NATSNotifier struct {
+	logger *zerolog.Logger
+	js     jetstream.JetStream
+}
+
+func NewNATS(logger *zerolog.Logger, js jetstream.JetStream) *NATSNotifier {
+	return &NATSNotifier{logger: logger, js: js}
+}
+
+func (n *NATSNotifier) Notify(ctx context.Context, event *events.Event) {
+	msg, err := events.Encode(event)
+	if err != nil {
+		n.logger.Error().Err(err).Str("id", event.ID).Msg("failed to encode event")
+		return
+	}
+
+	n.logger.Info().
+		Str("subject", event.Subject()).
+		Str("msg", string(msg)).
+		Msg("notify")
+
+	// the event outlives the request, so it is published even if the request is canceled
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), publishTimeout)
+	defer cancel()
+
+	ack, err := n.js.Publish(ctx, event.Subject(), msg)
+	if err != nil {
+		n.logger.Error().Err(err).Msg("failed to publish message to JetStream")
+		return
+	}
+
+	n.logger.Debug().Uint64("seq", ack.Sequence).Msg("message published")
+}
+
+// NotifyBatch publishes the events without waiting for each ack in turn,
+// then waits for all of them.
+func (n *NATSNotifier) NotifyBatch(ctx context.Context, batch []*events.Event) {
+	futures := make([]jetstream.PubAckFuture, 0, len(batch))
+	for _, event := range batch {
+		msg, err := events.Encode(event)
+		if err != nil {
+			n.logger.Error().Err(err).Str("id", event.ID).Msg("failed to encode event")
+			continue
+		}
+
+		future, err := n.js.PublishAsync(event.Subject(), msg)
+		if err != nil {
+			n.logger.Error().Err(err).Str("id", event.ID).Msg("failed to publish message to JetStream")
+			continue
+		}
+		futures = append(futures, future)
+	}
+
+	n.logger.Debug().Int("events", len(futures)).Msg("notify batch")
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), publishTimeout)
+	defer cancel()
+
+	for _, future := range futures {
+		select {
+		case ack := <-future.Ok():
+			n.logger.Debug().Uint64("seq", ack.Sequence).Msg("message published")
+		case err := <-future.Err():
+			n.logger.Error().Err(err).Str("subject", future.Msg().Subject).Msg("failed to publish message to JetStream")
+		case <-ctx.Done():
+			n.logger.Error().Err(ctx.Err()).Str("subject", future.Msg().Subject).Msg("failed to publish message to JetStream")
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package notifier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runJetStream(t *testing.T) (jetstream.JetStream, jetstream.Stream) {
+	t.Helper()
+
+	ns, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+
+	go ns.Start()
+	t.Cleanup(ns.Shutdown)
+	require.True(t, ns.ReadyForConnections(5*time.Second))
+
+	nc, err := nats.Connect(ns.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+
+	stream, err := EnsureStream(t.Context(), js, time.Hour)
+	require.NoError(t, err)
+
+	return js, stream
+}
+
+func TestNATSNotifier_NotifyBatch(t *testing.T) {
+	js, stream := runJetStream(t)
+
+	n := NewNATS(&log.Logger, js)
+	n.NotifyBatch(t.Context(), []*events.Event{
+		events.New(t.Context(), events.ActionCreate, 1, 1),
+		events.New(t.Context(), events.ActionCreate, 1, 2),
+		events.New(t.Context(), events.ActionCreate, 2, 3),
+	})
+
+	info, err := stream.Info(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), info.State.Msgs)
+
+	msg, err := stream.GetMsg(t.Context(), 3)
+	require.NoError(t, err)
+	assert.Equal(t, "products.2.reviews.3.created", msg.Subject)
+}
@@ -1,33 +1,40 @@
 package notifier
 
 import (
-	"fmt"
+	"context"
 
-	"github.com/lameaux/golang-product-reviews/model"
-	"github.com/nats-io/nats.go"
-	"github.com/rs/zerolog"
+	"github.com/lameaux/golang-product-reviews/events"
 )
 
-type Notifier struct {
-	logger   *zerolog.Logger
-	natsConn *nats.Conn
+// Notifier delivers change events. Delivery failures are handled
+// by the implementation, they never fail the change itself.
+type Notifier interface {
+	Notify(ctx context.Context, event *events.Event)
 }
 
-func New(logger *zerolog.Logger, natsConn *nats.Conn) *Notifier {
-	return &Notifier{logger: logger, natsConn: natsConn}
+// BatchNotifier is a Notifier that delivers several events at once,
+// e.g. with one round trip to the broker.
+type BatchNotifier interface {
+	Notifier
+	NotifyBatch(ctx context.Context, batch []*events.Event)
 }
 
-func (n *Notifier) Notify(productID model.ID, reviewID model.ID, action string) {
-	msg := fmt.Sprintf(
-		`{"product":%d,"review":%d,"action":"%s"}`,
-		productID, reviewID, action,
-	)
-
-	n.logger.Info().
-		Str("msg", msg).
-		Msg("notify")
+// NotifyBatch delivers the batch with n.NotifyBatch if n supports it,
+// or one event at a time otherwise.
+func NotifyBatch(ctx context.Context, n Notifier, batch []*events.Event) {
+	if bn, ok := n.(BatchNotifier); ok {
+		bn.NotifyBatch(ctx, batch)
+		return
+	}
 
-	if err := n.natsConn.Publish("reviews", []byte(msg)); err != nil {
-		n.logger.Error().Err(err).Msg("failed to publish message to NATS")
+	for _, event := range batch {
+		n.Notify(ctx, event)
 	}
 }
+
+// Func adapts an ordinary function to the Notifier interface.
+type Func func(ctx context.Context, event *events.Event)
+
+func (f Func) Notify(ctx context.Context, event *events.Event) {
+	f(ctx, event)
+}
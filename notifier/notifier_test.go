@@ -0,0 +1,246 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFanOut(t *testing.T) {
+	first := NewMemory()
+	second := NewMemory()
+
+	var called int
+	fanOut := NewFanOut(first, second, Func(func(context.Context, *events.Event) {
+		called++
+	}))
+
+	event := events.New(t.Context(), events.ActionCreate, 1, 2)
+	fanOut.Notify(t.Context(), event)
+
+	assert.Equal(t, []*events.Event{event}, first.Events())
+	assert.Equal(t, []*events.Event{event}, second.Events())
+	assert.Equal(t, 1, called)
+}
+
+// batchNotifier records the batches it gets.
+type batchNotifier struct {
+	*MemoryNotifier
+	batches [][]*events.Event
+}
+
+func (n *batchNotifier) NotifyBatch(ctx context.Context, batch []*events.Event) {
+	n.batches = append(n.batches, batch)
+	for _, event := range batch {
+		n.MemoryNotifier.Notify(ctx, event)
+	}
+}
+
+func TestNotifyBatch(t *testing.T) {
+	batch := []*events.Event{
+		events.New(t.Context(), events.ActionCreate, 1, 1),
+		events.New(t.Context(), events.ActionCreate, 1, 2),
+	}
+
+	single := NewMemory()
+	batched := &batchNotifier{MemoryNotifier: NewMemory()}
+	NotifyBatch(t.Context(), NewFanOut(single, batched), batch)
+
+	assert.Equal(t, batch, single.Events())
+	assert.Equal(t, [][]*events.Event{batch}, batched.batches)
+}
+
+func TestFileNotifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	n, err := NewFile(&log.Logger, path)
+	require.NoError(t, err)
+
+	created := events.New(t.Context(), events.ActionCreate, 1, 2)
+	deleted := events.New(t.Context(), events.ActionDelete, 1, 2)
+	n.Notify(t.Context(), created)
+	n.Notify(t.Context(), deleted)
+	n.Notify(t.Context(), &events.Event{Action: "invalid"})
+	require.NoError(t, n.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var decoded []*events.Event
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		event, err := events.Decode(scanner.Bytes())
+		require.NoError(t, err)
+		decoded = append(decoded, event)
+	}
+	require.NoError(t, scanner.Err())
+
+	assert.Equal(t, []*events.Event{created, deleted}, decoded)
+}
+
+// gatedNotifier blocks deliveries until the gate is opened.
+type gatedNotifier struct {
+	gate chan struct{}
+	*MemoryNotifier
+}
+
+func newGated() *gatedNotifier {
+	return &gatedNotifier{gate: make(chan struct{}), MemoryNotifier: NewMemory()}
+}
+
+func (n *gatedNotifier) Notify(ctx context.Context, event *events.Event) {
+	<-n.gate
+	n.MemoryNotifier.Notify(ctx, event)
+}
+
+func TestAsyncNotifier_FlushOnClose(t *testing.T) {
+	next := newGated()
+
+	n, err := NewAsync(&log.Logger, next, AsyncConfig{QueueSize: 10, Workers: 1, Overflow: OverflowBlock})
+	require.NoError(t, err)
+
+	var sent []*events.Event
+	for id := range 5 {
+		event := events.New(t.Context(), events.ActionCreate, 1, id+1)
+		n.Notify(t.Context(), event)
+		sent = append(sent, event)
+	}
+	assert.Empty(t, next.Events())
+
+	close(next.gate)
+	require.NoError(t, n.Close(t.Context()))
+
+	assert.Equal(t, sent, next.Events())
+}
+
+func TestAsyncNotifier_NotifyBatch(t *testing.T) {
+	next := &batchNotifier{MemoryNotifier: NewMemory()}
+
+	n, err := NewAsync(&log.Logger, next, AsyncConfig{QueueSize: 10, Workers: 1, Overflow: OverflowBlock})
+	require.NoError(t, err)
+
+	batch := []*events.Event{
+		events.New(t.Context(), events.ActionCreate, 1, 1),
+		events.New(t.Context(), events.ActionCreate, 2, 2),
+	}
+	n.NotifyBatch(t.Context(), batch)
+	require.NoError(t, n.Close(t.Context()))
+
+	assert.Equal(t, [][]*events.Event{batch}, next.batches)
+}
+
+func TestAsyncNotifier_DropOldest(t *testing.T) {
+	next := newGated()
+
+	n, err := NewAsync(&log.Logger, next, AsyncConfig{QueueSize: 2, Workers: 1, Overflow: OverflowDropOldest})
+	require.NoError(t, err)
+
+	first := events.New(t.Context(), events.ActionCreate, 1, 1)
+	n.Notify(t.Context(), first)
+	// the worker holds the first event
+	require.Eventually(t, func() bool { return len(n.queue) == 0 }, time.Second, time.Millisecond)
+
+	var sent []*events.Event
+	for id := range 4 {
+		event := events.New(t.Context(), events.ActionCreate, 1, id+2)
+		n.Notify(t.Context(), event)
+		sent = append(sent, event)
+	}
+
+	close(next.gate)
+	require.NoError(t, n.Close(t.Context()))
+
+	assert.Equal(t, []*events.Event{first, sent[2], sent[3]}, next.Events())
+}
+
+func TestAsyncNotifier_BatchCountsEvents(t *testing.T) {
+	next := newGated()
+
+	n, err := NewAsync(&log.Logger, next, AsyncConfig{QueueSize: 3, Workers: 1, Overflow: OverflowDropOldest})
+	require.NoError(t, err)
+
+	first := events.New(t.Context(), events.ActionCreate, 1, 1)
+	n.Notify(t.Context(), first)
+	// the worker holds the first event
+	require.Eventually(t, func() bool { return n.queued() == 0 }, time.Second, time.Millisecond)
+
+	dropped := []*events.Event{
+		events.New(t.Context(), events.ActionCreate, 1, 2),
+		events.New(t.Context(), events.ActionCreate, 1, 3),
+	}
+	n.NotifyBatch(t.Context(), dropped)
+
+	// two batches of two events don't fit into a queue of three
+	kept := []*events.Event{
+		events.New(t.Context(), events.ActionCreate, 1, 4),
+		events.New(t.Context(), events.ActionCreate, 1, 5),
+	}
+	n.NotifyBatch(t.Context(), kept)
+	assert.Equal(t, 2, n.queued())
+
+	close(next.gate)
+	require.NoError(t, n.Close(t.Context()))
+
+	assert.Equal(t, []*events.Event{first, kept[0], kept[1]}, next.Events())
+}
+
+func TestAsyncNotifier_BlockCanceled(t *testing.T) {
+	next := newGated()
+
+	n, err := NewAsync(&log.Logger, next, AsyncConfig{QueueSize: 1, Workers: 1, Overflow: OverflowBlock})
+	require.NoError(t, err)
+
+	n.Notify(t.Context(), events.New(t.Context(), events.ActionCreate, 1, 1))
+	require.Eventually(t, func() bool { return len(n.queue) == 0 }, time.Second, time.Millisecond)
+	n.Notify(t.Context(), events.New(t.Context(), events.ActionCreate, 1, 2))
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	n.Notify(ctx, events.New(ctx, events.ActionCreate, 1, 3))
+
+	close(next.gate)
+	require.NoError(t, n.Close(t.Context()))
+
+	assert.Len(t, next.Events(), 2)
+}
+
+func TestAsyncNotifier_Spill(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill.jsonl")
+	cfg := AsyncConfig{QueueSize: 1, Workers: 1, Overflow: OverflowSpill, SpillPath: path}
+
+	next := newGated()
+	n, err := NewAsync(&log.Logger, next, cfg)
+	require.NoError(t, err)
+
+	var sent []*events.Event
+	for id := range 4 {
+		event := events.New(t.Context(), events.ActionCreate, 1, id+1)
+		n.Notify(t.Context(), event)
+		sent = append(sent, event)
+		// the worker holds the first event, the second one is queued
+		require.Eventually(t, func() bool { return len(n.queue) == 0 || id > 0 }, time.Second, time.Millisecond)
+	}
+
+	close(next.gate)
+	require.NoError(t, n.Close(t.Context()))
+	assert.Equal(t, sent[:2], next.Events())
+
+	// spilled events are restored on the next start
+	restored := NewMemory()
+	n, err = NewAsync(&log.Logger, restored, cfg)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return len(restored.Events()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, n.Close(t.Context()))
+
+	assert.Equal(t, sent[2:], restored.Events())
+}
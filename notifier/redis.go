@@ -0,0 +1,48 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+var _ Notifier = (*RedisNotifier)(nil)
+
+const redisStreamMaxLen = 100_000
+
+// RedisNotifier adds events to a Redis stream, trimmed to about redisStreamMaxLen entries.
+type RedisNotifier struct {
+	logger *zerolog.Logger
+	client *redis.Client
+	stream string
+}
+
+func NewRedis(logger *zerolog.Logger, client *redis.Client, stream string) *RedisNotifier {
+	return &RedisNotifier{logger: logger, client: client, stream: stream}
+}
+
+func (n *RedisNotifier) Notify(ctx context.Context, event *events.Event) {
+	msg, err := events.Encode(event)
+	if err != nil {
+		n.logger.Error().Err(err).Str("id", event.ID).Msg("failed to encode event")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), publishTimeout)
+	defer cancel()
+
+	id, err := n.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: n.stream,
+		MaxLen: redisStreamMaxLen,
+		Approx: true,
+		Values: map[string]any{"subject": event.Subject(), "event": msg},
+	}).Result()
+	if err != nil {
+		n.logger.Error().Err(err).Str("stream", n.stream).Msg("failed to add event to Redis stream")
+		return
+	}
+
+	n.logger.Debug().Str("stream", n.stream).Str("entry", id).Msg("event added")
+}
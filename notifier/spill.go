@@ -0,0 +1,114 @@
+package notifier
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/lameaux/golang-product-reviews/events"
+)
+
+// spillFile is an overflow queue on disk, one JSON event per line.
+type spillFile struct {
+	mu    sync.Mutex
+	path  string
+	file  *os.File
+	count int
+}
+
+func openSpillFile(path string) (*spillFile, error) {
+	s := &spillFile{path: path}
+
+	// events left from the previous run are restored first
+	spilled, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	s.count = len(spilled)
+
+	if s.file, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600); err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+
+	return s, nil
+}
+
+func (s *spillFile) append(event *events.Event) error {
+	msg, err := events.Encode(event)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Write(append(msg, '\n')); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	s.count++
+
+	return nil
+}
+
+func (s *spillFile) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.count
+}
+
+// drain returns all spilled events and empties the file.
+func (s *spillFile) drain() ([]*events.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	spilled, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.file.Truncate(0); err != nil {
+		return nil, fmt.Errorf("truncate: %w", err)
+	}
+	s.count = 0
+
+	return spilled, nil
+}
+
+func (s *spillFile) read() ([]*events.Event, error) {
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", s.path, err)
+	}
+	defer file.Close()
+
+	var spilled []*events.Event
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		event, err := events.Decode(scanner.Bytes())
+		if err != nil {
+			// a partly written line, the event is lost
+			continue
+		}
+		spilled = append(spilled, event)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", s.path, err)
+	}
+
+	return spilled, nil
+}
+
+func (s *spillFile) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
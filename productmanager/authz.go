@@ -0,0 +1,79 @@
+package productmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrForbidden = errors.New("forbidden")
+
+// Authorizer decides whether the caller in ctx may make the call.
+type Authorizer interface {
+	Authorize(ctx context.Context, call *Call) error
+}
+
+// Authorization rejects calls the authorizer doesn't allow.
+func Authorization(authorizer Authorizer) Decorator {
+	return Intercept(func(ctx context.Context, call *Call, next func(ctx context.Context) error) error {
+		if err := authorizer.Authorize(ctx, call); err != nil {
+			return err
+		}
+
+		return next(ctx)
+	})
+}
+
+type principalKey struct{}
+
+// WithPrincipal stores the authenticated identity of the caller. Unlike the
+// actor of events.WithActor it is never taken from what the client claims.
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) string {
+	principal, _ := ctx.Value(principalKey{}).(string)
+	return principal
+}
+
+var _ Authorizer = (*RoleAuthorizer)(nil)
+
+// RoleAuthorizer authorizes the principal of the request, see WithPrincipal.
+// Everyone may read, writes need a writer or an admin, admin calls need an admin.
+type RoleAuthorizer struct {
+	writers map[string]struct{}
+	admins  map[string]struct{}
+}
+
+func NewRoleAuthorizer(writers []string, admins []string) *RoleAuthorizer {
+	a := &RoleAuthorizer{writers: make(map[string]struct{}), admins: make(map[string]struct{})}
+	for _, principal := range writers {
+		a.writers[principal] = struct{}{}
+	}
+	for _, principal := range admins {
+		a.admins[principal] = struct{}{}
+	}
+	return a
+}
+
+func (a *RoleAuthorizer) Authorize(ctx context.Context, call *Call) error {
+	if call.Kind == KindRead {
+		return nil
+	}
+
+	principal := PrincipalFromContext(ctx)
+	if principal == "" {
+		return fmt.Errorf("%w: anonymous callers may not call %s", ErrForbidden, call.Method)
+	}
+
+	if _, ok := a.admins[principal]; ok {
+		return nil
+	}
+
+	if _, ok := a.writers[principal]; ok && call.Kind == KindWrite {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %q may not call %s", ErrForbidden, principal, call.Method)
+}
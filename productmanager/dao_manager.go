@@ -2,34 +2,52 @@ package productmanager
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/lameaux/golang-product-reviews/cache"
 	"github.com/lameaux/golang-product-reviews/database"
 	"github.com/lameaux/golang-product-reviews/dto"
+	"github.com/lameaux/golang-product-reviews/events"
 	"github.com/lameaux/golang-product-reviews/lock"
 	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/lameaux/golang-product-reviews/notifier"
+	"github.com/rs/zerolog"
+	"golang.org/x/sync/singleflight"
 )
 
 var _ Manager = (*DAOManager)(nil)
 
-type NotifyFunc func(productID model.ID, reviewID model.ID, action string)
+// listingLockID is the single-flight lock of listing pages, product IDs start at 1.
+const listingLockID model.ID = 0
 
 type DAOManager struct {
-	dao        database.DAO
-	cacheDAO   cache.DAO
-	lock       lock.Lock
-	notifyFunc NotifyFunc
+	dao      database.DAO
+	cacheDAO cache.DAO
+	lock     lock.Lock
+	notifier notifier.Notifier
+	// loads coalesces concurrent loads of the same value
+	loads singleflight.Group
+	// refreshing holds keys of stale values being refreshed in the background
+	refreshing sync.Map
+	// traffic orders products for cache warm-up
+	traffic *traffic
+	logger  *zerolog.Logger
 }
 
 func New(
 	dao database.DAO,
 	cacheDAO cache.DAO,
 	lock lock.Lock,
-	notifyFunc NotifyFunc,
+	notifier notifier.Notifier,
 ) *DAOManager {
-	return &DAOManager{dao: dao, cacheDAO: cacheDAO, lock: lock, notifyFunc: notifyFunc}
+	nop := zerolog.Nop()
+	return &DAOManager{dao: dao, cacheDAO: cacheDAO, lock: lock, notifier: notifier, traffic: newTraffic(), logger: &nop}
+}
+
+// SetLogger logs failures that don't fail the call, e.g. of single products of a warm-up.
+func (m *DAOManager) SetLogger(logger *zerolog.Logger) {
+	m.logger = logger
 }
 
 func (m *DAOManager) CreateProduct(ctx context.Context, p *dto.Product) (model.ID, error) {
@@ -44,10 +62,24 @@ func (m *DAOManager) CreateProduct(ctx context.Context, p *dto.Product) (model.I
 		return 0, fmt.Errorf("dao.CreateProduct: %w", err)
 	}
 
+	// drops a tombstone cached by reads of the ID before it existed
+	m.cacheDAO.InvalidateProduct(ctx, productID)
+	m.cacheDAO.InvalidateProductListing(ctx)
+
+	product.ID = productID
+	event := events.New(ctx, events.ActionCreate, productID, 0)
+	event.Product = &events.ProductChange{After: events.NewProduct(product)}
+	m.notifier.Notify(ctx, event)
+
 	return productID, nil
 }
 
 func (m *DAOManager) UpdateProduct(ctx context.Context, productID model.ID, p *dto.Product) error {
+	before, err := m.dao.GetProduct(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("dao.GetProduct: %w", err)
+	}
+
 	product := &model.Product{
 		ID:          productID,
 		Name:        p.Name,
@@ -59,30 +91,56 @@ func (m *DAOManager) UpdateProduct(ctx context.Context, productID model.ID, p *d
 		return fmt.Errorf("dao.UpdateProduct: %w", err)
 	}
 
+	m.productUpdated(ctx, before, product)
+
 	return nil
 }
 
+// productUpdated invalidates the product and sends an update event.
+func (m *DAOManager) productUpdated(ctx context.Context, before *model.Product, product *model.Product) {
+	m.cacheDAO.InvalidateProduct(ctx, product.ID)
+	m.cacheDAO.InvalidateProductListing(ctx)
+
+	event := events.New(ctx, events.ActionUpdate, product.ID, 0)
+	event.Product = &events.ProductChange{Before: events.NewProduct(before), After: events.NewProduct(product)}
+	if before != nil && before.Price != product.Price {
+		event.Price = &events.PriceChange{Old: before.Price, New: product.Price}
+	}
+	m.notifier.Notify(ctx, event)
+}
+
 func (m *DAOManager) DeleteProduct(ctx context.Context, productID model.ID) error {
+	before, err := m.dao.GetProduct(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("dao.GetProduct: %w", err)
+	}
+
 	if err := m.dao.DeleteProduct(ctx, productID); err != nil {
 		return fmt.Errorf("dao.DeleteProduct: %w", err)
 	}
 
 	m.cacheDAO.InvalidateProduct(ctx, productID)
-	m.notifyFunc(productID, 0, "delete")
+	m.cacheDAO.InvalidateProductListing(ctx)
+
+	event := events.New(ctx, events.ActionDelete, productID, 0)
+	event.Product = &events.ProductChange{Before: events.NewProduct(before)}
+	m.notifier.Notify(ctx, event)
 
 	return nil
 }
 
 func (m *DAOManager) GetProduct(ctx context.Context, productID model.ID) (*dto.ProductWithRating, error) {
-	product, err := m.dao.GetProduct(ctx, productID)
+	product, err := m.getProduct(ctx, productID)
 	if err != nil {
-		return nil, fmt.Errorf("dao.GetProduct: %w", err)
+		return nil, fmt.Errorf("getProduct: %w", err)
 	}
 
 	if product == nil {
 		return nil, nil
 	}
 
+	m.traffic.record(productID)
+
 	rating, err := m.getProductRating(ctx, product.ID)
 	if err != nil {
 		return nil, fmt.Errorf("getProductRating: %w", err)
@@ -92,9 +150,9 @@ func (m *DAOManager) GetProduct(ctx context.Context, productID model.ID) (*dto.P
 }
 
 func (m *DAOManager) ListProducts(ctx context.Context, offset int, limit int) ([]*dto.ProductWithRating, error) {
-	products, err := m.dao.ListProducts(ctx, offset, limit)
+	products, err := m.listProducts(ctx, offset, limit)
 	if err != nil {
-		return nil, fmt.Errorf("dao.ListProducts: %w", err)
+		return nil, fmt.Errorf("listProducts: %w", err)
 	}
 
 	result := make([]*dto.ProductWithRating, 0, len(products))
@@ -110,52 +168,91 @@ func (m *DAOManager) ListProducts(ctx context.Context, offset int, limit int) ([
 	return result, nil
 }
 
-func convertProductWithRating(product *model.Product, rating float32) *dto.ProductWithRating {
-	return &dto.ProductWithRating{
-		Product: dto.Product{
-			ID:          product.ID,
-			Name:        product.Name,
-			Description: product.Description,
-			Price:       product.Price,
+// getProduct releases the lock before the rating is loaded, which takes the same lock.
+func (m *DAOManager) getProduct(ctx context.Context, id model.ID) (*model.Product, error) {
+	return readThrough(ctx, m, cachedValue[*model.Product]{
+		key:    fmt.Sprintf("product:%d", id),
+		lockID: id,
+		get: func(ctx context.Context) (*model.Product, error) {
+			return m.cacheDAO.GetProduct(ctx, id)
 		},
-		Rating: rating,
-	}
+		generation: func(ctx context.Context) (cache.Generation, error) {
+			return m.cacheDAO.ProductGeneration(ctx, id)
+		},
+		load: func(ctx context.Context) (*model.Product, error) {
+			product, err := m.dao.GetProduct(ctx, id)
+			if err != nil {
+				return nil, fmt.Errorf("dao.GetProduct: %w", err)
+			}
+			return product, nil
+		},
+		// a missing product is cached as a tombstone
+		set: func(ctx context.Context, load cache.Load, product *model.Product) {
+			m.cacheDAO.SetProduct(ctx, id, load, product)
+		},
+	})
 }
 
-func (m *DAOManager) getProductRating(ctx context.Context, id model.ID) (float32, error) {
-	rating, err := m.cacheDAO.GetProductRating(ctx, id)
-	if err != nil {
-		if !errors.Is(err, cache.NotFound) {
-			return 0, err
-		}
-	} else {
-		return rating, nil
-	}
-
-	// single flight
-	if err := m.lock.Lock(ctx, id); err != nil {
-		return 0, fmt.Errorf("lock.Lock: %w", err)
-	}
-	defer m.lock.Unlock(ctx, id)
+func (m *DAOManager) listProducts(ctx context.Context, offset int, limit int) ([]*model.Product, error) {
+	return readThrough(ctx, m, cachedValue[[]*model.Product]{
+		key:    fmt.Sprintf("listing:%d:%d", offset, limit),
+		lockID: listingLockID,
+		get: func(ctx context.Context) ([]*model.Product, error) {
+			return m.cacheDAO.GetProducts(ctx, offset, limit)
+		},
+		generation: func(ctx context.Context) (cache.Generation, error) {
+			return m.cacheDAO.ListingGeneration(ctx)
+		},
+		load: func(ctx context.Context) ([]*model.Product, error) {
+			products, err := m.dao.ListProducts(ctx, offset, limit)
+			if err != nil {
+				return nil, fmt.Errorf("dao.ListProducts: %w", err)
+			}
+			return products, nil
+		},
+		set: func(ctx context.Context, load cache.Load, products []*model.Product) {
+			m.cacheDAO.SetProducts(ctx, load, offset, limit, products)
+		},
+	})
+}
 
-	// check again after obtaining lock
-	rating, err = m.cacheDAO.GetProductRating(ctx, id)
-	if err != nil {
-		if !errors.Is(err, cache.NotFound) {
-			return 0, err
-		}
-	} else {
-		return rating, nil
+func convertProduct(product *model.Product) *dto.Product {
+	return &dto.Product{
+		ID:          product.ID,
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
 	}
+}
 
-	rating, err = m.dao.GetProductRating(ctx, id)
-	if err != nil {
-		return 0, fmt.Errorf("dao.GetProductRating: %w", err)
+func convertProductWithRating(product *model.Product, rating float32) *dto.ProductWithRating {
+	return &dto.ProductWithRating{
+		Product: *convertProduct(product),
+		Rating:  rating,
 	}
+}
 
-	m.cacheDAO.SetProductRating(ctx, id, rating)
-
-	return rating, nil
+func (m *DAOManager) getProductRating(ctx context.Context, id model.ID) (float32, error) {
+	return readThrough(ctx, m, cachedValue[float32]{
+		key:    fmt.Sprintf("rating:%d", id),
+		lockID: id,
+		get: func(ctx context.Context) (float32, error) {
+			return m.cacheDAO.GetProductRating(ctx, id)
+		},
+		generation: func(ctx context.Context) (cache.Generation, error) {
+			return m.cacheDAO.ProductGeneration(ctx, id)
+		},
+		load: func(ctx context.Context) (float32, error) {
+			rating, err := m.dao.GetProductRating(ctx, id)
+			if err != nil {
+				return 0, fmt.Errorf("dao.GetProductRating: %w", err)
+			}
+			return rating, nil
+		},
+		set: func(ctx context.Context, load cache.Load, rating float32) {
+			m.cacheDAO.SetProductRating(ctx, id, load, rating)
+		},
+	})
 }
 
 func (m *DAOManager) CreateProductReview(ctx context.Context, productID model.ID, r *dto.Review) (model.ID, error) {
@@ -174,12 +271,68 @@ func (m *DAOManager) CreateProductReview(ctx context.Context, productID model.ID
 
 	m.cacheDAO.InvalidateProduct(ctx, productID)
 
-	m.notifyFunc(productID, reviewID, "create")
+	review.ID = reviewID
+	event := events.New(ctx, events.ActionCreate, productID, reviewID)
+	event.Review = &events.ReviewChange{After: events.NewReview(review)}
+	m.notifier.Notify(ctx, event)
 
 	return reviewID, nil
 }
 
+func (m *DAOManager) CreateProductReviews(ctx context.Context, reviews []*dto.BatchReview) ([]*dto.ReviewBatchItem, error) {
+	created := make([]*model.Review, 0, len(reviews))
+	for _, r := range reviews {
+		created = append(created, &model.Review{
+			ProductID: r.ProductID,
+			FirstName: r.FirstName,
+			LastName:  r.LastName,
+			Review:    r.Review.Review,
+			Rating:    r.Rating,
+		})
+	}
+
+	if err := m.dao.CreateProductReviews(ctx, created); err != nil {
+		return nil, fmt.Errorf("dao.CreateProductReviews: %w", err)
+	}
+
+	items := make([]*dto.ReviewBatchItem, 0, len(created))
+	invalidated := make(map[model.ID]bool)
+	var batch []*events.Event
+
+	for i, review := range created {
+		item := &dto.ReviewBatchItem{Index: i, ProductID: review.ProductID, ID: review.ID, Status: dto.BatchItemCreated}
+		items = append(items, item)
+
+		if review.ID == 0 {
+			item.Status = dto.BatchItemNotFound
+			continue
+		}
+
+		if !invalidated[review.ProductID] {
+			m.cacheDAO.InvalidateProduct(ctx, review.ProductID)
+			invalidated[review.ProductID] = true
+		}
+
+		event := events.New(ctx, events.ActionCreate, review.ProductID, review.ID)
+		event.Review = &events.ReviewChange{After: events.NewReview(review)}
+		batch = append(batch, event)
+	}
+
+	notifier.NotifyBatch(ctx, m.notifier, batch)
+
+	return items, nil
+}
+
 func (m *DAOManager) UpdateProductReview(ctx context.Context, productID model.ID, reviewID model.ID, r *dto.Review) error {
+	before, err := m.dao.GetProductReview(ctx, reviewID)
+	if err != nil {
+		return fmt.Errorf("dao.GetProductReview: %w", err)
+	}
+
+	if before == nil || before.ProductID != productID {
+		return nil
+	}
+
 	review := &model.Review{
 		ID:        reviewID,
 		ProductID: productID,
@@ -193,102 +346,100 @@ func (m *DAOManager) UpdateProductReview(ctx context.Context, productID model.ID
 		return fmt.Errorf("dao.UpdateProductReview: %w", err)
 	}
 
-	m.cacheDAO.InvalidateProduct(ctx, productID)
-
-	m.notifyFunc(productID, reviewID, "update")
+	m.reviewUpdated(ctx, before, review)
 
 	return nil
 }
 
+// reviewUpdated invalidates the product of the review and sends an update event.
+func (m *DAOManager) reviewUpdated(ctx context.Context, before *model.Review, review *model.Review) {
+	m.cacheDAO.InvalidateProduct(ctx, review.ProductID)
+
+	event := events.New(ctx, events.ActionUpdate, review.ProductID, review.ID)
+	event.Review = &events.ReviewChange{Before: events.NewReview(before), After: events.NewReview(review)}
+	m.notifier.Notify(ctx, event)
+}
+
 func (m *DAOManager) DeleteProductReview(ctx context.Context, productID model.ID, reviewID model.ID) error {
+	before, err := m.dao.GetProductReview(ctx, reviewID)
+	if err != nil {
+		return fmt.Errorf("dao.GetProductReview: %w", err)
+	}
+
+	// nothing is deleted or published for a review of another product
+	if before == nil || before.ProductID != productID {
+		return nil
+	}
+
 	if err := m.dao.DeleteProductReview(ctx, reviewID); err != nil {
 		return fmt.Errorf("dao.DeleteProductReview: %w", err)
 	}
 
 	m.cacheDAO.InvalidateProduct(ctx, productID)
 
-	m.notifyFunc(productID, reviewID, "delete")
+	event := events.New(ctx, events.ActionDelete, productID, reviewID)
+	event.Review = &events.ReviewChange{Before: events.NewReview(before)}
+	m.notifier.Notify(ctx, event)
 
 	return nil
 }
 
 func (m *DAOManager) GetProductReview(ctx context.Context, productID model.ID, reviewID model.ID) (*dto.Review, error) {
-	review, err := m.cacheDAO.GetProductReview(ctx, productID, reviewID)
-	if err != nil {
-		if !errors.Is(err, cache.NotFound) {
-			return nil, err
-		}
-	} else {
-		return convertReview(review), nil
-	}
-
-	// single flight
-	if err := m.lock.Lock(ctx, productID); err != nil {
-		return nil, fmt.Errorf("lock.Lock: %w", err)
-	}
-	defer m.lock.Unlock(ctx, productID)
-
-	// check again after obtaining lock
-	review, err = m.cacheDAO.GetProductReview(ctx, productID, reviewID)
-	if err != nil {
-		if !errors.Is(err, cache.NotFound) {
-			return nil, err
-		}
-	} else {
-		return convertReview(review), nil
-	}
-
-	review, err = m.dao.GetProductReview(ctx, reviewID)
-	if err != nil {
-		return nil, fmt.Errorf("dao.GetProductReview: %w", err)
-	}
-
-	if review == nil {
-		return nil, nil
+	review, err := readThrough(ctx, m, cachedValue[*model.Review]{
+		key:    fmt.Sprintf("review:%d:%d", productID, reviewID),
+		lockID: productID,
+		get: func(ctx context.Context) (*model.Review, error) {
+			return m.cacheDAO.GetProductReview(ctx, productID, reviewID)
+		},
+		generation: func(ctx context.Context) (cache.Generation, error) {
+			return m.cacheDAO.ProductGeneration(ctx, productID)
+		},
+		load: func(ctx context.Context) (*model.Review, error) {
+			review, err := m.dao.GetProductReview(ctx, reviewID)
+			if err != nil {
+				return nil, fmt.Errorf("dao.GetProductReview: %w", err)
+			}
+			return review, nil
+		},
+		// a missing review is cached as a tombstone
+		set: func(ctx context.Context, load cache.Load, review *model.Review) {
+			m.cacheDAO.SetProductReview(ctx, productID, load, reviewID, review)
+		},
+	})
+	if err != nil || review == nil {
+		return nil, err
 	}
 
-	m.cacheDAO.SetProductReview(ctx, productID, reviewID, review)
-
 	return convertReview(review), nil
 }
 
 func (m *DAOManager) ListProductReviews(ctx context.Context, productID model.ID, offset int, limit int) ([]*dto.Review, error) {
-	reviews, err := m.cacheDAO.GetProductReviews(ctx, productID, offset, limit)
-	if err != nil {
-		if !errors.Is(err, cache.NotFound) {
-			return nil, err
-		}
-	} else {
-		return convertReviews(reviews), nil
-	}
-
-	// single flight
-	if err := m.lock.Lock(ctx, productID); err != nil {
-		return nil, fmt.Errorf("lock.Lock: %w", err)
-	}
-	defer m.lock.Unlock(ctx, productID)
-
-	// check again after obtaining lock
-	reviews, err = m.cacheDAO.GetProductReviews(ctx, productID, offset, limit)
-	if err != nil {
-		if !errors.Is(err, cache.NotFound) {
-			return nil, err
-		}
-	} else {
-		return convertReviews(reviews), nil
-	}
-
-	reviews, err = m.dao.ListProductReviews(ctx, productID, offset, limit)
+	reviews, err := readThrough(ctx, m, cachedValue[[]*model.Review]{
+		key:    fmt.Sprintf("reviews:%d:%d:%d", productID, offset, limit),
+		lockID: productID,
+		get: func(ctx context.Context) ([]*model.Review, error) {
+			return m.cacheDAO.GetProductReviews(ctx, productID, offset, limit)
+		},
+		generation: func(ctx context.Context) (cache.Generation, error) {
+			return m.cacheDAO.ProductGeneration(ctx, productID)
+		},
+		load: func(ctx context.Context) ([]*model.Review, error) {
+			reviews, err := m.dao.ListProductReviews(ctx, productID, offset, limit)
+			if err != nil {
+				return nil, fmt.Errorf("dao.ListProductReviews: %w", err)
+			}
+			return reviews, nil
+		},
+		set: func(ctx context.Context, load cache.Load, reviews []*model.Review) {
+			if len(reviews) > 0 {
+				m.cacheDAO.SetProductReviews(ctx, productID, load, offset, limit, reviews)
+			}
+		},
+	})
 	if err != nil {
-		return nil, fmt.Errorf("dao.ListProductReviews: %w", err)
+		return nil, err
 	}
 
-	if len(reviews) == 0 {
-		return []*dto.Review{}, nil
-	}
-
-	m.cacheDAO.SetProductReviews(ctx, productID, offset, limit, reviews)
-
 	return convertReviews(reviews), nil
 }
 
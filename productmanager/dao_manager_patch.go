@@ -0,0 +1,119 @@
+package productmanager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lameaux/golang-product-reviews/dto"
+	"github.com/lameaux/golang-product-reviews/model"
+)
+
+func (m *DAOManager) PatchProduct(ctx context.Context, productID model.ID, apply func(p *dto.Product) error) (*dto.Product, error) {
+	before, err := m.dao.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("dao.GetProduct: %w", err)
+	}
+
+	if before == nil {
+		return nil, nil
+	}
+
+	p := convertProduct(before)
+	if err := apply(p); err != nil {
+		return nil, fmt.Errorf("apply: %w", err)
+	}
+
+	product := &model.Product{
+		ID:          productID,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       p.Price,
+	}
+
+	fields := changedProductFields(before, product)
+	if len(fields) == 0 {
+		return convertProduct(product), nil
+	}
+
+	if err := m.dao.PatchProduct(ctx, product, fields); err != nil {
+		return nil, fmt.Errorf("dao.PatchProduct: %w", err)
+	}
+
+	m.productUpdated(ctx, before, product)
+
+	return convertProduct(product), nil
+}
+
+func changedProductFields(before *model.Product, after *model.Product) []string {
+	var fields []string
+	if before.Name != after.Name {
+		fields = append(fields, "Name")
+	}
+	if before.Description != after.Description {
+		fields = append(fields, "Description")
+	}
+	if before.Price != after.Price {
+		fields = append(fields, "Price")
+	}
+	return fields
+}
+
+func (m *DAOManager) PatchProductReview(
+	ctx context.Context,
+	productID model.ID,
+	reviewID model.ID,
+	apply func(r *dto.Review) error,
+) (*dto.Review, error) {
+	before, err := m.dao.GetProductReview(ctx, reviewID)
+	if err != nil {
+		return nil, fmt.Errorf("dao.GetProductReview: %w", err)
+	}
+
+	if before == nil || before.ProductID != productID {
+		return nil, nil
+	}
+
+	r := convertReview(before)
+	if err := apply(r); err != nil {
+		return nil, fmt.Errorf("apply: %w", err)
+	}
+
+	review := &model.Review{
+		ID:        reviewID,
+		ProductID: productID,
+		FirstName: r.FirstName,
+		LastName:  r.LastName,
+		Review:    r.Review,
+		Rating:    r.Rating,
+	}
+
+	fields := changedReviewFields(before, review)
+	if len(fields) == 0 {
+		return convertReview(review), nil
+	}
+
+	if err := m.dao.PatchProductReview(ctx, review, fields); err != nil {
+		return nil, fmt.Errorf("dao.PatchProductReview: %w", err)
+	}
+
+	m.reviewUpdated(ctx, before, review)
+
+	return convertReview(review), nil
+}
+
+func changedReviewFields(before *model.Review, after *model.Review) []string {
+	var fields []string
+	if before.FirstName != after.FirstName {
+		fields = append(fields, "FirstName")
+	}
+	if before.LastName != after.LastName {
+		fields = append(fields, "LastName")
+	}
+	if before.Review != after.Review {
+		fields = append(fields, "Review")
+	}
+	if before.Rating != after.Rating {
+		fields = append(fields, "Rating")
+	}
+	return fields
+}
@@ -0,0 +1,153 @@
+package productmanager
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lameaux/golang-product-reviews/dto"
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/lameaux/golang-product-reviews/notifier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDAOManager_PatchProduct(t *testing.T) {
+	dao := new(mockedDAO)
+	dao.On("GetProduct", mock.Anything, 1).Return(&model.Product{
+		ID:          1,
+		Name:        "P1",
+		Description: "P1 desc",
+		Price:       80,
+	}, nil)
+	dao.On("PatchProduct", mock.Anything, &model.Product{
+		ID:          1,
+		Name:        "P1",
+		Description: "P1 desc",
+		Price:       100,
+	}, []string{"Price"}).Return(nil)
+
+	cacheDAO := new(mockedCache)
+	cacheDAO.On("InvalidateProduct", mock.Anything, 1).Once()
+	cacheDAO.On("InvalidateProductListing", mock.Anything).Once()
+
+	notified := 0
+	m := New(dao, cacheDAO, nil, notifier.Func(func(ctx context.Context, event *events.Event) {
+		notified++
+		assert.Equal(t, "products.1.updated", event.Subject())
+		assert.Equal(t, &events.PriceChange{Old: 80, New: 100}, event.Price)
+	}))
+
+	product, err := m.PatchProduct(t.Context(), 1, func(p *dto.Product) error {
+		p.Price = 100
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, &dto.Product{ID: 1, Name: "P1", Description: "P1 desc", Price: 100}, product)
+	assert.Equal(t, 1, notified)
+	dao.AssertExpectations(t)
+	cacheDAO.AssertExpectations(t)
+}
+
+func TestDAOManager_PatchProduct_Unchanged(t *testing.T) {
+	dao := new(mockedDAO)
+	dao.On("GetProduct", mock.Anything, 1).Return(&model.Product{ID: 1, Name: "P1", Description: "P1 desc", Price: 80}, nil)
+
+	m := New(dao, new(mockedCache), nil, notifier.Func(func(ctx context.Context, event *events.Event) {
+		t.Error("unexpected event")
+	}))
+
+	product, err := m.PatchProduct(t.Context(), 1, func(p *dto.Product) error {
+		p.Name = "P1"
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 80, int(product.Price))
+
+	dao.AssertNotCalled(t, "PatchProduct", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDAOManager_PatchProduct_Invalid(t *testing.T) {
+	dao := new(mockedDAO)
+	dao.On("GetProduct", mock.Anything, 1).Return(&model.Product{ID: 1, Name: "P1", Description: "P1 desc", Price: 80}, nil)
+
+	m := New(dao, new(mockedCache), nil, nil)
+
+	invalid := errors.New("invalid")
+	_, err := m.PatchProduct(t.Context(), 1, func(p *dto.Product) error {
+		return invalid
+	})
+	assert.ErrorIs(t, err, invalid)
+
+	dao.AssertNotCalled(t, "PatchProduct", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDAOManager_PatchProduct_Missing(t *testing.T) {
+	dao := new(mockedDAO)
+	dao.On("GetProduct", mock.Anything, 1).Return((*model.Product)(nil), nil)
+
+	m := New(dao, new(mockedCache), nil, nil)
+
+	product, err := m.PatchProduct(t.Context(), 1, func(p *dto.Product) error {
+		t.Error("unexpected apply")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Nil(t, product)
+}
+
+func TestDAOManager_PatchProductReview(t *testing.T) {
+	dao := new(mockedDAO)
+	dao.On("GetProductReview", mock.Anything, 1).Return(&model.Review{
+		ID:        1,
+		ProductID: 2,
+		FirstName: "Sergej",
+		LastName:  "Sizov",
+		Review:    "Good",
+		Rating:    4,
+	}, nil)
+	dao.On("PatchProductReview", mock.Anything, &model.Review{
+		ID:        1,
+		ProductID: 2,
+		FirstName: "Sergej",
+		LastName:  "Sizov",
+		Review:    "Excellent",
+		Rating:    5,
+	}, []string{"Review", "Rating"}).Return(nil)
+
+	cacheDAO := new(mockedCache)
+	cacheDAO.On("InvalidateProduct", mock.Anything, 2).Once()
+
+	m := New(dao, cacheDAO, nil, notifier.Func(func(ctx context.Context, event *events.Event) {
+		assert.Equal(t, events.ActionUpdate, event.Action)
+		assert.Equal(t, 1, event.ReviewID)
+	}))
+
+	review, err := m.PatchProductReview(t.Context(), 2, 1, func(r *dto.Review) error {
+		r.Review = "Excellent"
+		r.Rating = 5
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Excellent", review.Review)
+	dao.AssertExpectations(t)
+	cacheDAO.AssertExpectations(t)
+}
+
+func TestDAOManager_PatchProductReview_OtherProduct(t *testing.T) {
+	dao := new(mockedDAO)
+	dao.On("GetProductReview", mock.Anything, 1).Return(&model.Review{ID: 1, ProductID: 3}, nil)
+
+	m := New(dao, new(mockedCache), nil, nil)
+
+	review, err := m.PatchProductReview(t.Context(), 2, 1, func(r *dto.Review) error {
+		t.Error("unexpected apply")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Nil(t, review)
+}
@@ -1,13 +1,20 @@
 package productmanager
 
 import (
+	"context"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/lameaux/golang-product-reviews/cache"
 	"github.com/lameaux/golang-product-reviews/dto"
+	"github.com/lameaux/golang-product-reviews/events"
 	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/lameaux/golang-product-reviews/notifier"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDAOManager_CreateProduct(t *testing.T) {
@@ -18,7 +25,16 @@ func TestDAOManager_CreateProduct(t *testing.T) {
 		Price:       100,
 	}).Return(1, nil)
 
-	m := New(dao, nil, nil, nil)
+	cacheDAO := new(mockedCache)
+	cacheDAO.On("InvalidateProduct", mock.Anything, 1).Once()
+	cacheDAO.On("InvalidateProductListing", mock.Anything).Once()
+
+	m := New(dao, cacheDAO, nil, notifier.Func(func(ctx context.Context, event *events.Event) {
+		assert.Equal(t, "products.1.created", event.Subject())
+		assert.Equal(t, &events.ProductChange{
+			After: &events.Product{ID: 1, Name: "P1", Description: "P1 desc", Price: 100},
+		}, event.Product)
+	}))
 
 	p := &dto.Product{
 		Name:        "P1",
@@ -33,6 +49,12 @@ func TestDAOManager_CreateProduct(t *testing.T) {
 
 func TestDAOManager_UpdateProduct(t *testing.T) {
 	dao := new(mockedDAO)
+	dao.On("GetProduct", mock.Anything, 1).Return(&model.Product{
+		ID:          1,
+		Name:        "P1",
+		Description: "P1 desc",
+		Price:       80,
+	}, nil)
 	dao.On("UpdateProduct", mock.Anything, &model.Product{
 		ID:          1,
 		Name:        "P1",
@@ -40,7 +62,18 @@ func TestDAOManager_UpdateProduct(t *testing.T) {
 		Price:       100,
 	}).Return(nil)
 
-	m := New(dao, nil, nil, nil)
+	cacheDAO := new(mockedCache)
+	cacheDAO.On("InvalidateProduct", mock.Anything, 1).Once()
+	cacheDAO.On("InvalidateProductListing", mock.Anything).Once()
+
+	m := New(dao, cacheDAO, nil, notifier.Func(func(ctx context.Context, event *events.Event) {
+		assert.Equal(t, "products.1.updated", event.Subject())
+		assert.Equal(t, &events.PriceChange{Old: 80, New: 100}, event.Price)
+		assert.Equal(t, &events.ProductChange{
+			Before: &events.Product{ID: 1, Name: "P1", Description: "P1 desc", Price: 80},
+			After:  &events.Product{ID: 1, Name: "P1", Description: "P1 desc", Price: 100},
+		}, event.Product)
+	}))
 
 	p := &dto.Product{
 		Name:        "P1",
@@ -54,43 +87,58 @@ func TestDAOManager_UpdateProduct(t *testing.T) {
 
 func TestDAOManager_DeleteProduct(t *testing.T) {
 	dao := new(mockedDAO)
+	dao.On("GetProduct", mock.Anything, 1).Return(&model.Product{
+		ID:          1,
+		Name:        "P1",
+		Description: "P1 desc",
+		Price:       100,
+	}, nil)
 	dao.On("DeleteProduct", mock.Anything, 1).Return(nil)
 
 	cacheDAO := new(mockedCache)
 	cacheDAO.On("InvalidateProduct", mock.Anything, 1).Once()
+	cacheDAO.On("InvalidateProductListing", mock.Anything).Once()
 
-	m := New(dao, cacheDAO, nil, func(productID model.ID, reviewID model.ID, action string) {
-		assert.Equal(t, 1, productID)
-		assert.Equal(t, 0, reviewID)
-		assert.Equal(t, "delete", action)
-	})
+	m := New(dao, cacheDAO, nil, notifier.Func(func(ctx context.Context, event *events.Event) {
+		assert.Equal(t, "products.1.deleted", event.Subject())
+		assert.Nil(t, event.Price)
+		assert.Equal(t, &events.ProductChange{
+			Before: &events.Product{ID: 1, Name: "P1", Description: "P1 desc", Price: 100},
+		}, event.Product)
+	}))
 
 	err := m.DeleteProduct(t.Context(), 1)
 	assert.NoError(t, err)
 }
 
 func TestDAOManager_GetProduct(t *testing.T) {
-	dao := new(mockedDAO)
-	dao.On("GetProduct", mock.Anything, 1).Return(&model.Product{
+	product := &model.Product{
 		ID:          1,
 		Name:        "P1",
 		Description: "P1 desc",
 		Price:       100,
-	}, nil)
+	}
+
+	dao := new(mockedDAO)
+	dao.On("GetProduct", mock.Anything, 1).Return(product, nil)
 
 	dao.On("GetProductRating", mock.Anything, 1).Return(float32(4.9), nil)
 
 	cacheDAO := new(mockedCache)
+	cacheDAO.On("GetProduct", mock.Anything, 1).Return((*model.Product)(nil), cache.NotFound).Twice()
+	cacheDAO.On("ProductGeneration", mock.Anything, 1).Return(cache.Generation(7), nil)
+	// the lease of product 1 has token 1
+	cacheDAO.On("SetProduct", mock.Anything, 1, fencedLoadOf(7, 1), product).Once()
 	cacheDAO.On("GetProductRating", mock.Anything, 1).Return(float32(0), cache.NotFound).Twice()
-	cacheDAO.On("SetProductRating", mock.Anything, 1, float32(4.9)).Once()
+	cacheDAO.On("SetProductRating", mock.Anything, 1, fencedLoadOf(7, 1), float32(4.9)).Once()
 
 	lock := new(mockedLock)
 	lock.On("Lock", mock.Anything, 1).Return(nil)
-	lock.On("Unlock", mock.Anything, 1).Return(nil)
+	lock.On("Release", mock.Anything, 1).Return(nil)
 
 	m := New(dao, cacheDAO, lock, nil)
 
-	product, err := m.GetProduct(t.Context(), 1)
+	result, err := m.GetProduct(t.Context(), 1)
 	assert.NoError(t, err)
 
 	assert.Equal(t, &dto.ProductWithRating{
@@ -101,34 +149,97 @@ func TestDAOManager_GetProduct(t *testing.T) {
 			Price:       100,
 		},
 		Rating: 4.9,
-	}, product)
+	}, result)
+}
+
+func TestDAOManager_GetProduct_LeaseLost(t *testing.T) {
+	product := &model.Product{ID: 1, Name: "P1", Description: "P1 desc", Price: 100}
+
+	dao := new(mockedDAO)
+	dao.On("GetProduct", mock.Anything, 1).Return(product, nil).Once()
+
+	cacheDAO := new(mockedCache)
+	cacheDAO.On("GetProduct", mock.Anything, 1).Return((*model.Product)(nil), cache.NotFound).Twice()
+	cacheDAO.On("ProductGeneration", mock.Anything, 1).Return(cache.Generation(7), nil)
+
+	lock := &mockedLock{lost: make(chan struct{})}
+	lock.On("Lock", mock.Anything, 1).Return(nil).Once()
+	lock.On("Release", mock.Anything, 1).Return(nil).Once()
+	close(lock.lost)
+
+	m := New(dao, cacheDAO, lock, nil)
+
+	// the loaded value is returned, but not cached without the lock
+	result, err := m.getProduct(t.Context(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, product, result)
+
+	cacheDAO.AssertNotCalled(t, "SetProduct", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	lock.AssertExpectations(t)
+}
+
+func TestDAOManager_GetProduct_Missing(t *testing.T) {
+	dao := new(mockedDAO)
+	dao.On("GetProduct", mock.Anything, 1).Return((*model.Product)(nil), nil).Once()
+
+	cacheDAO := new(mockedCache)
+	cacheDAO.On("GetProduct", mock.Anything, 1).Return((*model.Product)(nil), cache.NotFound).Twice()
+	cacheDAO.On("ProductGeneration", mock.Anything, 1).Return(cache.Generation(7), nil)
+	cacheDAO.On("SetProduct", mock.Anything, 1, loadOf(7), (*model.Product)(nil)).Once()
+
+	lock := new(mockedLock)
+	lock.On("Lock", mock.Anything, 1).Return(nil)
+	lock.On("Release", mock.Anything, 1).Return(nil)
+
+	m := New(dao, cacheDAO, lock, nil)
+
+	result, err := m.GetProduct(t.Context(), 1)
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+
+	// the tombstone answers the next read
+	cacheDAO.On("GetProduct", mock.Anything, 1).Return((*model.Product)(nil), nil).Once()
+
+	result, err = m.GetProduct(t.Context(), 1)
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+
+	dao.AssertExpectations(t)
+	cacheDAO.AssertExpectations(t)
 }
 
 func TestDAOManager_ListProducts(t *testing.T) {
+	products := []*model.Product{
+		{
+			ID:          1,
+			Name:        "P1",
+			Description: "P1 desc",
+			Price:       100,
+		},
+	}
+
 	dao := new(mockedDAO)
-	dao.On("ListProducts", mock.Anything, 0, 100).Return(
-		[]*model.Product{
-			{
-				ID:          1,
-				Name:        "P1",
-				Description: "P1 desc",
-				Price:       100,
-			},
-		}, nil)
+	dao.On("ListProducts", mock.Anything, 0, 100).Return(products, nil)
 
 	dao.On("GetProductRating", mock.Anything, 1).Return(float32(4.9), nil)
 
 	cacheDAO := new(mockedCache)
+	cacheDAO.On("GetProducts", mock.Anything, 0, 100).Return(([]*model.Product)(nil), cache.NotFound).Twice()
+	cacheDAO.On("ListingGeneration", mock.Anything).Return(cache.Generation(3), nil)
+	cacheDAO.On("SetProducts", mock.Anything, loadOf(3), 0, 100, products).Once()
+	cacheDAO.On("ProductGeneration", mock.Anything, 1).Return(cache.Generation(7), nil)
 	cacheDAO.On("GetProductRating", mock.Anything, 1).Return(float32(0), cache.NotFound).Twice()
-	cacheDAO.On("SetProductRating", mock.Anything, 1, float32(4.9)).Once()
+	cacheDAO.On("SetProductRating", mock.Anything, 1, loadOf(7), float32(4.9)).Once()
 
 	lock := new(mockedLock)
+	lock.On("Lock", mock.Anything, 0).Return(nil)
+	lock.On("Release", mock.Anything, 0).Return(nil)
 	lock.On("Lock", mock.Anything, 1).Return(nil)
-	lock.On("Unlock", mock.Anything, 1).Return(nil)
+	lock.On("Release", mock.Anything, 1).Return(nil)
 
 	m := New(dao, cacheDAO, lock, nil)
 
-	products, err := m.ListProducts(t.Context(), 0, 100)
+	result, err := m.ListProducts(t.Context(), 0, 100)
 	assert.NoError(t, err)
 
 	assert.Equal(t, []*dto.ProductWithRating{
@@ -141,5 +252,115 @@ func TestDAOManager_ListProducts(t *testing.T) {
 			},
 			Rating: 4.9,
 		},
-	}, products)
+	}, result)
+}
+
+func TestDAOManager_ListProducts_Cached(t *testing.T) {
+	cacheDAO := new(mockedCache)
+	cacheDAO.On("GetProducts", mock.Anything, 0, 100).Return([]*model.Product{
+		{ID: 1, Name: "P1", Description: "P1 desc", Price: 100},
+	}, nil).Once()
+	cacheDAO.On("GetProductRating", mock.Anything, 1).Return(float32(4.9), nil).Once()
+
+	m := New(new(mockedDAO), cacheDAO, nil, nil)
+
+	result, err := m.ListProducts(t.Context(), 0, 100)
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+
+	cacheDAO.AssertExpectations(t)
+}
+
+func TestDAOManager_GetProductRating_Stale(t *testing.T) {
+	dao := new(mockedDAO)
+	dao.On("GetProductRating", mock.Anything, 1).Return(float32(4.5), nil).Once()
+
+	refreshed := make(chan struct{})
+
+	cacheDAO := new(mockedCache)
+	cacheDAO.On("GetProductRating", mock.Anything, 1).Return(float32(4), cache.Stale).Times(3)
+	cacheDAO.On("ProductGeneration", mock.Anything, 1).Return(cache.Generation(7), nil).Once()
+	cacheDAO.On("SetProductRating", mock.Anything, 1, loadOf(7), float32(4.5)).Once()
+
+	lock := new(mockedLock)
+	lock.On("Lock", mock.Anything, 1).Return(nil).Once()
+	lock.On("Release", mock.Anything, 1).Return(nil).Once().Run(func(mock.Arguments) {
+		close(refreshed)
+	})
+
+	m := New(dao, cacheDAO, lock, nil)
+
+	// the background refresh is started once, callers get the stale value
+	m.refreshing.Store("rating:1", struct{}{})
+	rating, err := m.getProductRating(t.Context(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, float32(4), rating)
+	m.refreshing.Delete("rating:1")
+
+	rating, err = m.getProductRating(t.Context(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, float32(4), rating)
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("rating was not refreshed")
+	}
+
+	dao.AssertExpectations(t)
+	cacheDAO.AssertExpectations(t)
+}
+
+func TestDAOManager_GetProductRating_Coalesced(t *testing.T) {
+	const callers = 5
+
+	loading := make(chan struct{})
+	loaded := make(chan struct{})
+
+	dao := new(mockedDAO)
+	dao.On("GetProductRating", mock.Anything, 1).Return(float32(4.5), nil).Once().Run(func(mock.Arguments) {
+		close(loading)
+		<-loaded
+	})
+
+	var gets atomic.Int32
+	cacheDAO := new(mockedCache)
+	cacheDAO.On("GetProductRating", mock.Anything, 1).Return(float32(0), cache.NotFound).Run(func(mock.Arguments) {
+		gets.Add(1)
+	})
+	cacheDAO.On("ProductGeneration", mock.Anything, 1).Return(cache.Generation(7), nil).Once()
+	cacheDAO.On("SetProductRating", mock.Anything, 1, loadOf(7), float32(4.5)).Once()
+
+	lock := new(mockedLock)
+	lock.On("Lock", mock.Anything, 1).Return(nil).Once()
+	lock.On("Release", mock.Anything, 1).Return(nil).Once()
+
+	m := New(dao, cacheDAO, lock, nil)
+
+	var wg sync.WaitGroup
+	ratings := make(chan float32, callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rating, err := m.getProductRating(t.Context(), 1)
+			assert.NoError(t, err)
+			ratings <- rating
+		}()
+	}
+
+	// the leader reads the cache twice, the others join its load after one miss
+	<-loading
+	require.Eventually(t, func() bool { return gets.Load() == callers+1 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	close(loaded)
+
+	wg.Wait()
+	close(ratings)
+	for rating := range ratings {
+		assert.Equal(t, float32(4.5), rating)
+	}
+
+	dao.AssertExpectations(t)
+	lock.AssertExpectations(t)
 }
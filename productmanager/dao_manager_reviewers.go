@@ -0,0 +1,73 @@
+package productmanager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/dto"
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/lameaux/golang-product-reviews/model"
+)
+
+func (m *DAOManager) ExportReviewerData(ctx context.Context, reviewer *dto.Reviewer) (*dto.ReviewerExport, error) {
+	reviews, err := m.dao.ListReviewerReviews(ctx, reviewer.FirstName, reviewer.LastName)
+	if err != nil {
+		return nil, fmt.Errorf("dao.ListReviewerReviews: %w", err)
+	}
+
+	entry := newErasureLogEntry(reviewer, model.ErasureModeExport)
+	entry.ReviewCount = len(reviews)
+
+	if err := m.dao.AppendErasureLog(ctx, entry); err != nil {
+		return nil, fmt.Errorf("dao.AppendErasureLog: %w", err)
+	}
+
+	result := &dto.ReviewerExport{
+		Reviewer: *reviewer,
+		Reviews:  make([]*dto.ReviewerReview, 0, len(reviews)),
+	}
+	for _, review := range reviews {
+		result.Reviews = append(result.Reviews, &dto.ReviewerReview{
+			ProductID: review.ProductID,
+			Review:    *convertReview(review),
+		})
+	}
+
+	return result, nil
+}
+
+func (m *DAOManager) EraseReviewerData(ctx context.Context, req *dto.ErasureRequest) (*dto.ErasureResult, error) {
+	entry := newErasureLogEntry(&req.Reviewer, req.Mode)
+
+	reviews, err := m.dao.EraseReviewerReviews(ctx, req.FirstName, req.LastName, entry)
+	if err != nil {
+		return nil, fmt.Errorf("dao.EraseReviewerReviews: %w", err)
+	}
+
+	invalidated := make(map[model.ID]struct{})
+	for _, review := range reviews {
+		if _, ok := invalidated[review.ProductID]; !ok {
+			m.cacheDAO.InvalidateProduct(ctx, review.ProductID)
+			invalidated[review.ProductID] = struct{}{}
+		}
+
+		// no snapshots, the event must not carry the erased personal data
+		m.notifier.Notify(ctx, events.New(ctx, events.ActionErase, review.ProductID, review.ID))
+	}
+
+	return &dto.ErasureResult{
+		Mode:    entry.Mode,
+		Reviews: entry.ReviewCount,
+		Hash:    entry.Hash,
+	}, nil
+}
+
+func newErasureLogEntry(reviewer *dto.Reviewer, mode string) *model.ErasureLogEntry {
+	return &model.ErasureLogEntry{
+		// postgres keeps microseconds, truncate so the hash can be verified later
+		RequestedAt: time.Now().UTC().Truncate(time.Microsecond),
+		SubjectHash: model.SubjectHash(reviewer.FirstName, reviewer.LastName),
+		Mode:        mode,
+	}
+}
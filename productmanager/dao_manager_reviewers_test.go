@@ -0,0 +1,153 @@
+package productmanager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/audit"
+	"github.com/lameaux/golang-product-reviews/database"
+	"github.com/lameaux/golang-product-reviews/dto"
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/lameaux/golang-product-reviews/notifier"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDAOManager_ExportReviewerData(t *testing.T) {
+	dao := new(mockedDAO)
+	dao.On("ListReviewerReviews", mock.Anything, "Sergej", "Sizov").Return([]*model.Review{
+		{
+			ID:        1,
+			ProductID: 2,
+			FirstName: "Sergej",
+			LastName:  "Sizov",
+			Review:    "Excellent",
+			Rating:    5,
+		},
+	}, nil)
+	dao.On("AppendErasureLog", mock.Anything, mock.MatchedBy(func(entry *model.ErasureLogEntry) bool {
+		return entry.Mode == model.ErasureModeExport &&
+			entry.ReviewCount == 1 &&
+			entry.SubjectHash == model.SubjectHash("Sergej", "Sizov")
+	})).Return(nil).Once()
+
+	m := New(dao, nil, nil, nil)
+
+	export, err := m.ExportReviewerData(t.Context(), &dto.Reviewer{FirstName: "Sergej", LastName: "Sizov"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, &dto.ReviewerExport{
+		Reviewer: dto.Reviewer{FirstName: "Sergej", LastName: "Sizov"},
+		Reviews: []*dto.ReviewerReview{
+			{
+				ProductID: 2,
+				Review: dto.Review{
+					ID:        1,
+					FirstName: "Sergej",
+					LastName:  "Sizov",
+					Review:    "Excellent",
+					Rating:    5,
+				},
+			},
+		},
+	}, export)
+	dao.AssertExpectations(t)
+}
+
+func TestDAOManager_EraseReviewerData(t *testing.T) {
+	dao := new(mockedDAO)
+	dao.On("EraseReviewerReviews", mock.Anything, "Sergej", "Sizov", mock.Anything).
+		Run(func(args mock.Arguments) {
+			entry := args.Get(3).(*model.ErasureLogEntry)
+			entry.ReviewCount = 3
+			entry.Hash = "hash"
+		}).
+		Return([]*model.Review{
+			{ID: 1, ProductID: 2},
+			{ID: 2, ProductID: 2},
+			{ID: 3, ProductID: 3},
+		}, nil)
+
+	cacheDAO := new(mockedCache)
+	cacheDAO.On("InvalidateProduct", mock.Anything, 2).Once()
+	cacheDAO.On("InvalidateProduct", mock.Anything, 3).Once()
+
+	memoryNotifier := notifier.NewMemory()
+	m := New(dao, cacheDAO, nil, memoryNotifier)
+
+	result, err := m.EraseReviewerData(t.Context(), &dto.ErasureRequest{
+		Reviewer: dto.Reviewer{FirstName: "Sergej", LastName: "Sizov"},
+		Mode:     model.ErasureModeDelete,
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, &dto.ErasureResult{Mode: model.ErasureModeDelete, Reviews: 3, Hash: "hash"}, result)
+
+	var notified []model.ID
+	for _, event := range memoryNotifier.Events() {
+		assert.Equal(t, events.ActionErase, event.Action)
+		assert.Nil(t, event.Review)
+		notified = append(notified, event.ReviewID)
+	}
+	assert.Equal(t, []model.ID{1, 2, 3}, notified)
+	cacheDAO.AssertExpectations(t)
+}
+
+// memoryAuditDAO keeps the audit trail in memory.
+type memoryAuditDAO struct {
+	events []*model.AuditEvent
+}
+
+func (d *memoryAuditDAO) CreateAuditEvent(_ context.Context, event *model.AuditEvent) error {
+	d.events = append(d.events, event)
+	return nil
+}
+
+func (d *memoryAuditDAO) ListAuditEvents(_ context.Context, _ *database.AuditFilter) ([]*model.AuditEvent, error) {
+	return d.events, nil
+}
+
+func TestDAOManager_EraseReviewerData_AuditTrail(t *testing.T) {
+	dao := new(mockedDAO)
+	dao.On("CreateProductReview", mock.Anything, mock.Anything).Return(1, nil)
+	dao.On("EraseReviewerReviews", mock.Anything, "Sergej", "Sizov", mock.Anything).
+		Return([]*model.Review{{ID: 1, ProductID: 2}}, nil)
+
+	cacheDAO := new(mockedCache)
+	cacheDAO.On("InvalidateProduct", mock.Anything, 2)
+
+	auditDAO := &memoryAuditDAO{}
+	recorder := audit.NewRecorder(&log.Logger, auditDAO)
+	var seq uint64
+	m := New(dao, cacheDAO, nil, notifier.Func(func(ctx context.Context, event *events.Event) {
+		data, err := events.Encode(event)
+		require.NoError(t, err)
+
+		seq++
+		require.NoError(t, recorder.Record(ctx, seq, time.Now(), data))
+	}))
+
+	_, err := m.CreateProductReview(t.Context(), 2, &dto.Review{
+		FirstName: "Sergej",
+		LastName:  "Sizov",
+		Review:    "Excellent",
+		Rating:    5,
+	})
+	require.NoError(t, err)
+
+	_, err = m.EraseReviewerData(t.Context(), &dto.ErasureRequest{
+		Reviewer: dto.Reviewer{FirstName: "Sergej", LastName: "Sizov"},
+		Mode:     model.ErasureModeAnonymize,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, auditDAO.events, 2)
+	for _, event := range auditDAO.events {
+		assert.NotContains(t, event.Payload, "Sergej")
+		assert.NotContains(t, event.Payload, "Sizov")
+	}
+}
@@ -1,11 +1,14 @@
 package productmanager
 
 import (
+	"context"
 	"testing"
 
 	"github.com/lameaux/golang-product-reviews/cache"
 	"github.com/lameaux/golang-product-reviews/dto"
+	"github.com/lameaux/golang-product-reviews/events"
 	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/lameaux/golang-product-reviews/notifier"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -23,11 +26,14 @@ func TestDAOManager_CreateProductReview(t *testing.T) {
 	cacheDAO := new(mockedCache)
 	cacheDAO.On("InvalidateProduct", mock.Anything, 2).Once()
 
-	m := New(dao, cacheDAO, nil, func(productID model.ID, reviewID model.ID, action string) {
-		assert.Equal(t, 2, productID)
-		assert.Equal(t, 1, reviewID)
-		assert.Equal(t, "create", action)
-	})
+	m := New(dao, cacheDAO, nil, notifier.Func(func(ctx context.Context, event *events.Event) {
+		assert.Equal(t, 2, event.ProductID)
+		assert.Equal(t, 1, event.ReviewID)
+		assert.Equal(t, events.ActionCreate, event.Action)
+		assert.Equal(t, &events.ReviewChange{
+			After: &events.Review{ID: 1, ProductID: 2, Review: "Excellent", Rating: 5},
+		}, event.Review)
+	}))
 
 	review := &dto.Review{
 		FirstName: "Sergej",
@@ -41,8 +47,66 @@ func TestDAOManager_CreateProductReview(t *testing.T) {
 	assert.Equal(t, 1, id)
 }
 
+// batchRecorder records the batches of NotifyBatch.
+type batchRecorder struct {
+	notifier.Func
+	batches [][]*events.Event
+}
+
+func (n *batchRecorder) NotifyBatch(ctx context.Context, batch []*events.Event) {
+	n.batches = append(n.batches, batch)
+}
+
+func TestDAOManager_CreateProductReviews(t *testing.T) {
+	dao := new(mockedDAO)
+	dao.On("CreateProductReviews", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		reviews := args.Get(1).([]*model.Review)
+		// the third review is of a product that doesn't exist
+		reviews[0].ID = 10
+		reviews[1].ID = 11
+		reviews[3].ID = 12
+	})
+
+	cacheDAO := new(mockedCache)
+	cacheDAO.On("InvalidateProduct", mock.Anything, 1).Once()
+	cacheDAO.On("InvalidateProduct", mock.Anything, 2).Once()
+
+	n := &batchRecorder{Func: func(context.Context, *events.Event) {
+		t.Error("events are expected in a batch")
+	}}
+	m := New(dao, cacheDAO, nil, n)
+
+	review := func(productID model.ID) *dto.BatchReview {
+		return &dto.BatchReview{ProductID: productID, Review: dto.Review{FirstName: "Sergej", LastName: "Sizov", Review: "Good", Rating: 4}}
+	}
+
+	items, err := m.CreateProductReviews(t.Context(), []*dto.BatchReview{review(1), review(1), review(3), review(2)})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []*dto.ReviewBatchItem{
+		{Index: 0, Status: dto.BatchItemCreated, ProductID: 1, ID: 10},
+		{Index: 1, Status: dto.BatchItemCreated, ProductID: 1, ID: 11},
+		{Index: 2, Status: dto.BatchItemNotFound, ProductID: 3},
+		{Index: 3, Status: dto.BatchItemCreated, ProductID: 2, ID: 12},
+	}, items)
+
+	cacheDAO.AssertExpectations(t)
+
+	if assert.Len(t, n.batches, 1) && assert.Len(t, n.batches[0], 3) {
+		assert.Equal(t, "products.2.reviews.12.created", n.batches[0][2].Subject())
+	}
+}
+
 func TestDAOManager_UpdateProductReview(t *testing.T) {
 	dao := new(mockedDAO)
+	dao.On("GetProductReview", mock.Anything, 1).Return(&model.Review{
+		ID:        1,
+		ProductID: 2,
+		FirstName: "Sergej",
+		LastName:  "Sizov",
+		Review:    "Good",
+		Rating:    4,
+	}, nil)
 	dao.On("UpdateProductReview", mock.Anything, &model.Review{
 		ID:        1,
 		ProductID: 2,
@@ -55,11 +119,15 @@ func TestDAOManager_UpdateProductReview(t *testing.T) {
 	cacheDAO := new(mockedCache)
 	cacheDAO.On("InvalidateProduct", mock.Anything, 2).Once()
 
-	m := New(dao, cacheDAO, nil, func(productID model.ID, reviewID model.ID, action string) {
-		assert.Equal(t, 2, productID)
-		assert.Equal(t, 1, reviewID)
-		assert.Equal(t, "update", action)
-	})
+	m := New(dao, cacheDAO, nil, notifier.Func(func(ctx context.Context, event *events.Event) {
+		assert.Equal(t, 2, event.ProductID)
+		assert.Equal(t, 1, event.ReviewID)
+		assert.Equal(t, events.ActionUpdate, event.Action)
+		assert.Equal(t, &events.ReviewChange{
+			Before: &events.Review{ID: 1, ProductID: 2, Review: "Good", Rating: 4},
+			After:  &events.Review{ID: 1, ProductID: 2, Review: "Excellent", Rating: 5},
+		}, event.Review)
+	}))
 
 	review := &dto.Review{
 		FirstName: "Sergej",
@@ -74,21 +142,62 @@ func TestDAOManager_UpdateProductReview(t *testing.T) {
 
 func TestDAOManager_DeleteProductReview(t *testing.T) {
 	dao := new(mockedDAO)
+	dao.On("GetProductReview", mock.Anything, 1).Return(&model.Review{
+		ID:        1,
+		ProductID: 2,
+		FirstName: "Sergej",
+		LastName:  "Sizov",
+		Review:    "Good",
+		Rating:    4,
+	}, nil)
 	dao.On("DeleteProductReview", mock.Anything, 1).Return(nil)
 
 	cacheDAO := new(mockedCache)
 	cacheDAO.On("InvalidateProduct", mock.Anything, 2).Once()
 
-	m := New(dao, cacheDAO, nil, func(productID model.ID, reviewID model.ID, action string) {
-		assert.Equal(t, 2, productID)
-		assert.Equal(t, 1, reviewID)
-		assert.Equal(t, "delete", action)
-	})
+	m := New(dao, cacheDAO, nil, notifier.Func(func(ctx context.Context, event *events.Event) {
+		assert.Equal(t, 2, event.ProductID)
+		assert.Equal(t, 1, event.ReviewID)
+		assert.Equal(t, events.ActionDelete, event.Action)
+		assert.Equal(t, &events.ReviewChange{
+			Before: &events.Review{ID: 1, ProductID: 2, Review: "Good", Rating: 4},
+		}, event.Review)
+	}))
 
 	err := m.DeleteProductReview(t.Context(), 2, 1)
 	assert.NoError(t, err)
 }
 
+func TestDAOManager_ReviewOfOtherProduct(t *testing.T) {
+	tests := []struct {
+		name   string
+		review *model.Review
+	}{
+		{name: "missing"},
+		{name: "other product", review: &model.Review{ID: 1, ProductID: 3, Review: "Good", Rating: 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dao := new(mockedDAO)
+			dao.On("GetProductReview", mock.Anything, 1).Return(tt.review, nil)
+
+			cacheDAO := new(mockedCache)
+
+			m := New(dao, cacheDAO, nil, notifier.Func(func(ctx context.Context, event *events.Event) {
+				t.Errorf("unexpected event %s", event.Subject())
+			}))
+
+			assert.NoError(t, m.UpdateProductReview(t.Context(), 2, 1, &dto.Review{Review: "Excellent", Rating: 5}))
+			assert.NoError(t, m.DeleteProductReview(t.Context(), 2, 1))
+
+			dao.AssertNotCalled(t, "UpdateProductReview", mock.Anything, mock.Anything)
+			dao.AssertNotCalled(t, "DeleteProductReview", mock.Anything, mock.Anything)
+			cacheDAO.AssertNotCalled(t, "InvalidateProduct", mock.Anything, mock.Anything)
+		})
+	}
+}
+
 func TestDAOManager_GetProductReview(t *testing.T) {
 	review := &model.Review{
 		ID:        1,
@@ -104,11 +213,12 @@ func TestDAOManager_GetProductReview(t *testing.T) {
 
 	cacheDAO := new(mockedCache)
 	cacheDAO.On("GetProductReview", mock.Anything, 2, 1).Return((*model.Review)(nil), cache.NotFound).Twice()
-	cacheDAO.On("SetProductReview", mock.Anything, 2, 1, review).Once()
+	cacheDAO.On("ProductGeneration", mock.Anything, 2).Return(cache.Generation(7), nil)
+	cacheDAO.On("SetProductReview", mock.Anything, 2, loadOf(7), 1, review).Once()
 
 	lock := new(mockedLock)
 	lock.On("Lock", mock.Anything, 2).Return(nil)
-	lock.On("Unlock", mock.Anything, 2).Return(nil)
+	lock.On("Release", mock.Anything, 2).Return(nil)
 
 	m := New(dao, cacheDAO, lock, nil)
 
@@ -141,11 +251,12 @@ func TestDAOManager_ListProductReviews(t *testing.T) {
 
 	cacheDAO := new(mockedCache)
 	cacheDAO.On("GetProductReviews", mock.Anything, 2, 0, 100).Return(([]*model.Review)(nil), cache.NotFound).Twice()
-	cacheDAO.On("SetProductReviews", mock.Anything, 2, 0, 100, reviews).Once()
+	cacheDAO.On("ProductGeneration", mock.Anything, 2).Return(cache.Generation(7), nil)
+	cacheDAO.On("SetProductReviews", mock.Anything, 2, loadOf(7), 0, 100, reviews).Once()
 
 	lock := new(mockedLock)
 	lock.On("Lock", mock.Anything, 2).Return(nil)
-	lock.On("Unlock", mock.Anything, 2).Return(nil)
+	lock.On("Release", mock.Anything, 2).Return(nil)
 
 	m := New(dao, cacheDAO, lock, nil)
 
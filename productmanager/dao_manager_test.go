@@ -20,6 +20,8 @@ type mockedCache struct {
 
 type mockedLock struct {
 	mock.Mock
+	// lost is returned by Lost of all leases, nil never fires
+	lost chan struct{}
 }
 
 var _ database.DAO = (*mockedDAO)(nil)
@@ -36,6 +38,11 @@ func (m *mockedDAO) UpdateProduct(ctx context.Context, product *model.Product) e
 	return args.Error(0)
 }
 
+func (m *mockedDAO) PatchProduct(ctx context.Context, product *model.Product, fields []string) error {
+	args := m.Called(ctx, product, fields)
+	return args.Error(0)
+}
+
 func (m *mockedDAO) DeleteProduct(ctx context.Context, id model.ID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -56,14 +63,29 @@ func (m *mockedDAO) ListProducts(ctx context.Context, offset int, limit int) ([]
 	return args.Get(0).([]*model.Product), args.Error(1)
 }
 
+func (m *mockedDAO) TopReviewedProducts(ctx context.Context, limit int) ([]model.ID, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]model.ID), args.Error(1)
+}
+
 func (m *mockedDAO) CreateProductReview(ctx context.Context, review *model.Review) (model.ID, error) {
 	args := m.Called(ctx, review)
 	return args.Int(0), args.Error(1)
 }
+func (m *mockedDAO) CreateProductReviews(ctx context.Context, reviews []*model.Review) error {
+	args := m.Called(ctx, reviews)
+	return args.Error(0)
+}
+
 func (m *mockedDAO) UpdateProductReview(ctx context.Context, review *model.Review) error {
 	args := m.Called(ctx, review)
 	return args.Error(0)
 }
+func (m *mockedDAO) PatchProductReview(ctx context.Context, review *model.Review, fields []string) error {
+	args := m.Called(ctx, review, fields)
+	return args.Error(0)
+}
+
 func (m *mockedDAO) DeleteProductReview(ctx context.Context, reviewID model.ID) error {
 	args := m.Called(ctx, reviewID)
 	return args.Error(0)
@@ -77,16 +99,63 @@ func (m *mockedDAO) ListProductReviews(ctx context.Context, productID model.ID,
 	return args.Get(0).([]*model.Review), args.Error(1)
 }
 
+func (m *mockedDAO) ListReviewerReviews(ctx context.Context, firstName string, lastName string) ([]*model.Review, error) {
+	args := m.Called(ctx, firstName, lastName)
+	return args.Get(0).([]*model.Review), args.Error(1)
+}
+
+func (m *mockedDAO) EraseReviewerReviews(ctx context.Context, firstName string, lastName string, entry *model.ErasureLogEntry) ([]*model.Review, error) {
+	args := m.Called(ctx, firstName, lastName, entry)
+	return args.Get(0).([]*model.Review), args.Error(1)
+}
+
+func (m *mockedDAO) AppendErasureLog(ctx context.Context, entry *model.ErasureLogEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
 func (m *mockedCache) InvalidateProduct(ctx context.Context, productID model.ID) {
 	m.Called(ctx, productID)
 }
 
+func (m *mockedCache) InvalidateProductListing(ctx context.Context) {
+	m.Called(ctx)
+}
+
+func (m *mockedCache) ProductGeneration(ctx context.Context, productID model.ID) (cache.Generation, error) {
+	args := m.Called(ctx, productID)
+	return args.Get(0).(cache.Generation), args.Error(1)
+}
+
+func (m *mockedCache) ListingGeneration(ctx context.Context) (cache.Generation, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(cache.Generation), args.Error(1)
+}
+
+func (m *mockedCache) GetProduct(ctx context.Context, productID model.ID) (*model.Product, error) {
+	args := m.Called(ctx, productID)
+	return args.Get(0).(*model.Product), args.Error(1)
+}
+
+func (m *mockedCache) SetProduct(ctx context.Context, productID model.ID, load cache.Load, product *model.Product) {
+	m.Called(ctx, productID, load, product)
+}
+
+func (m *mockedCache) GetProducts(ctx context.Context, offset int, limit int) ([]*model.Product, error) {
+	args := m.Called(ctx, offset, limit)
+	return args.Get(0).([]*model.Product), args.Error(1)
+}
+
+func (m *mockedCache) SetProducts(ctx context.Context, load cache.Load, offset int, limit int, products []*model.Product) {
+	m.Called(ctx, load, offset, limit, products)
+}
+
 func (m *mockedCache) GetProductRating(ctx context.Context, productID model.ID) (float32, error) {
 	args := m.Called(ctx, productID)
 	return args.Get(0).(float32), args.Error(1)
 }
-func (m *mockedCache) SetProductRating(ctx context.Context, productID model.ID, rating float32) {
-	m.Called(ctx, productID, rating)
+func (m *mockedCache) SetProductRating(ctx context.Context, productID model.ID, load cache.Load, rating float32) {
+	m.Called(ctx, productID, load, rating)
 }
 
 func (m *mockedCache) GetProductReviews(ctx context.Context, productID model.ID, offset int, limit int) ([]*model.Review, error) {
@@ -94,8 +163,8 @@ func (m *mockedCache) GetProductReviews(ctx context.Context, productID model.ID,
 	return args.Get(0).([]*model.Review), args.Error(1)
 }
 
-func (m *mockedCache) SetProductReviews(ctx context.Context, productID model.ID, offset int, limit int, reviews []*model.Review) {
-	m.Called(ctx, productID, offset, limit, reviews)
+func (m *mockedCache) SetProductReviews(ctx context.Context, productID model.ID, load cache.Load, offset int, limit int, reviews []*model.Review) {
+	m.Called(ctx, productID, load, offset, limit, reviews)
 }
 
 func (m *mockedCache) GetProductReview(ctx context.Context, productID model.ID, reviewID model.ID) (*model.Review, error) {
@@ -103,16 +172,47 @@ func (m *mockedCache) GetProductReview(ctx context.Context, productID model.ID,
 	return args.Get(0).(*model.Review), args.Error(1)
 }
 
-func (m *mockedCache) SetProductReview(ctx context.Context, productID model.ID, reviewID model.ID, review *model.Review) {
-	m.Called(ctx, productID, reviewID, review)
+func (m *mockedCache) SetProductReview(ctx context.Context, productID model.ID, load cache.Load, reviewID model.ID, review *model.Review) {
+	m.Called(ctx, productID, load, reviewID, review)
 }
 
-func (m *mockedLock) Lock(ctx context.Context, productID model.ID) error {
+func (m *mockedLock) Lock(ctx context.Context, productID model.ID) (lock.Lease, error) {
 	args := m.Called(ctx, productID)
-	return args.Error(0)
+	if err := args.Error(0); err != nil {
+		return nil, err
+	}
+	return &mockedLease{lock: m, productID: productID}, nil
 }
 
-func (m *mockedLock) Unlock(ctx context.Context, productID model.ID) error {
-	args := m.Called(ctx, productID)
+// mockedLease records Release on its lock.
+type mockedLease struct {
+	lock      *mockedLock
+	productID model.ID
+}
+
+func (l *mockedLease) Token() uint64 {
+	return uint64(l.productID)
+}
+
+func (l *mockedLease) Lost() <-chan struct{} {
+	return l.lock.lost
+}
+
+func (l *mockedLease) Release(ctx context.Context) error {
+	args := l.lock.Called(ctx, l.productID)
 	return args.Error(0)
 }
+
+// fencedLoadOf matches a cache.Load of the generation and fencing token.
+func fencedLoadOf(gen cache.Generation, fence uint64) any {
+	return mock.MatchedBy(func(load cache.Load) bool {
+		return load.Generation == gen && load.Fence == fence
+	})
+}
+
+// loadOf matches a cache.Load of the generation.
+func loadOf(gen cache.Generation) any {
+	return mock.MatchedBy(func(load cache.Load) bool {
+		return load.Generation == gen
+	})
+}
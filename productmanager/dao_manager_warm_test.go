@@ -0,0 +1,70 @@
+package productmanager
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/dto"
+	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDAOManager_WarmCache(t *testing.T) {
+	dao := new(mockedDAO)
+	dao.On("TopReviewedProducts", mock.Anything, DefaultWarmLimit).Return([]model.ID{1, 2, 3}, nil).Once()
+
+	cacheDAO := new(mockedCache)
+	// cached product
+	cacheDAO.On("GetProduct", mock.Anything, 1).Return(&model.Product{ID: 1}, nil).Once()
+	cacheDAO.On("GetProductRating", mock.Anything, 1).Return(float32(4.5), nil).Once()
+	cacheDAO.On("GetProductReviews", mock.Anything, 1, 0, warmReviewsLimit).Return([]*model.Review{{ID: 1, ProductID: 1}}, nil).Once()
+	// tombstone of a deleted product
+	cacheDAO.On("GetProduct", mock.Anything, 2).Return((*model.Product)(nil), nil).Once()
+	// cache failure
+	cacheDAO.On("GetProduct", mock.Anything, 3).Return((*model.Product)(nil), errors.New("redis down")).Once()
+
+	var logs bytes.Buffer
+	logger := zerolog.New(&logs)
+
+	m := New(dao, cacheDAO, nil, nil)
+	m.SetLogger(&logger)
+
+	result, err := m.WarmCache(t.Context(), &dto.CacheWarmRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, &dto.CacheWarmResult{By: WarmByReviews, Products: 3, Failed: 1}, result)
+
+	// the failure is logged with its product
+	assert.Contains(t, logs.String(), `"level":"warn"`)
+	assert.Contains(t, logs.String(), `"product":3`)
+	assert.Contains(t, logs.String(), "redis down")
+
+	dao.AssertExpectations(t)
+	cacheDAO.AssertExpectations(t)
+}
+
+func TestTraffic_Top(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tr := newTraffic()
+	tr.now = func() time.Time { return now }
+
+	for _, id := range []model.ID{1, 2, 2, 3, 3, 3} {
+		tr.record(id)
+	}
+	assert.Equal(t, []model.ID{3, 2}, tr.top(2))
+
+	// the previous window still counts
+	now = now.Add(trafficWindow)
+	tr.record(1)
+	tr.record(1)
+	assert.Equal(t, []model.ID{1, 3, 2}, tr.top(10))
+
+	// reads older than two windows are dropped
+	now = now.Add(2 * trafficWindow)
+	assert.Empty(t, tr.top(10))
+}
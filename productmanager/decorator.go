@@ -0,0 +1,207 @@
+package productmanager
+
+import (
+	"context"
+
+	"github.com/lameaux/golang-product-reviews/dto"
+	"github.com/lameaux/golang-product-reviews/model"
+)
+
+// Kind groups Manager methods for decorators, e.g. to authorize them.
+type Kind string
+
+const (
+	KindRead  Kind = "read"
+	KindWrite Kind = "write"
+	// KindAdmin is for bulk and data subject operations.
+	KindAdmin Kind = "admin"
+)
+
+// Call describes a call of a Manager method.
+type Call struct {
+	Method    string
+	Kind      Kind
+	ProductID model.ID
+	ReviewID  model.ID
+}
+
+// Interceptor runs around a call, next calls the decorated Manager.
+type Interceptor func(ctx context.Context, call *Call, next func(ctx context.Context) error) error
+
+// Decorator wraps a Manager with cross-cutting behavior.
+type Decorator func(next Manager) Manager
+
+// Decorate wraps m with decorators, the first one is the outermost.
+func Decorate(m Manager, decorators ...Decorator) Manager {
+	for i := len(decorators) - 1; i >= 0; i-- {
+		m = decorators[i](m)
+	}
+	return m
+}
+
+// Intercept returns a Decorator running every call through the interceptor.
+func Intercept(interceptor Interceptor) Decorator {
+	return func(next Manager) Manager {
+		return &intercepted{next: next, intercept: interceptor}
+	}
+}
+
+var _ Manager = (*intercepted)(nil)
+
+type intercepted struct {
+	next      Manager
+	intercept Interceptor
+}
+
+func (m *intercepted) CreateProduct(ctx context.Context, p *dto.Product) (model.ID, error) {
+	var productID model.ID
+	err := m.intercept(ctx, &Call{Method: "CreateProduct", Kind: KindWrite}, func(ctx context.Context) error {
+		var err error
+		productID, err = m.next.CreateProduct(ctx, p)
+		return err
+	})
+	return productID, err
+}
+
+func (m *intercepted) UpdateProduct(ctx context.Context, productID model.ID, p *dto.Product) error {
+	return m.intercept(ctx, &Call{Method: "UpdateProduct", Kind: KindWrite, ProductID: productID}, func(ctx context.Context) error {
+		return m.next.UpdateProduct(ctx, productID, p)
+	})
+}
+
+func (m *intercepted) PatchProduct(ctx context.Context, productID model.ID, apply func(p *dto.Product) error) (*dto.Product, error) {
+	var product *dto.Product
+	err := m.intercept(ctx, &Call{Method: "PatchProduct", Kind: KindWrite, ProductID: productID}, func(ctx context.Context) error {
+		var err error
+		product, err = m.next.PatchProduct(ctx, productID, apply)
+		return err
+	})
+	return product, err
+}
+
+func (m *intercepted) DeleteProduct(ctx context.Context, productID model.ID) error {
+	return m.intercept(ctx, &Call{Method: "DeleteProduct", Kind: KindWrite, ProductID: productID}, func(ctx context.Context) error {
+		return m.next.DeleteProduct(ctx, productID)
+	})
+}
+
+func (m *intercepted) GetProduct(ctx context.Context, productID model.ID) (*dto.ProductWithRating, error) {
+	var product *dto.ProductWithRating
+	err := m.intercept(ctx, &Call{Method: "GetProduct", Kind: KindRead, ProductID: productID}, func(ctx context.Context) error {
+		var err error
+		product, err = m.next.GetProduct(ctx, productID)
+		return err
+	})
+	return product, err
+}
+
+func (m *intercepted) ListProducts(ctx context.Context, offset int, limit int) ([]*dto.ProductWithRating, error) {
+	var products []*dto.ProductWithRating
+	err := m.intercept(ctx, &Call{Method: "ListProducts", Kind: KindRead}, func(ctx context.Context) error {
+		var err error
+		products, err = m.next.ListProducts(ctx, offset, limit)
+		return err
+	})
+	return products, err
+}
+
+func (m *intercepted) CreateProductReview(ctx context.Context, productID model.ID, r *dto.Review) (model.ID, error) {
+	var reviewID model.ID
+	err := m.intercept(ctx, &Call{Method: "CreateProductReview", Kind: KindWrite, ProductID: productID}, func(ctx context.Context) error {
+		var err error
+		reviewID, err = m.next.CreateProductReview(ctx, productID, r)
+		return err
+	})
+	return reviewID, err
+}
+
+func (m *intercepted) CreateProductReviews(ctx context.Context, reviews []*dto.BatchReview) ([]*dto.ReviewBatchItem, error) {
+	var items []*dto.ReviewBatchItem
+	err := m.intercept(ctx, &Call{Method: "CreateProductReviews", Kind: KindWrite}, func(ctx context.Context) error {
+		var err error
+		items, err = m.next.CreateProductReviews(ctx, reviews)
+		return err
+	})
+	return items, err
+}
+
+func (m *intercepted) DeleteProductReview(ctx context.Context, productID model.ID, reviewID model.ID) error {
+	call := &Call{Method: "DeleteProductReview", Kind: KindWrite, ProductID: productID, ReviewID: reviewID}
+	return m.intercept(ctx, call, func(ctx context.Context) error {
+		return m.next.DeleteProductReview(ctx, productID, reviewID)
+	})
+}
+
+func (m *intercepted) UpdateProductReview(ctx context.Context, productID model.ID, reviewID model.ID, review *dto.Review) error {
+	call := &Call{Method: "UpdateProductReview", Kind: KindWrite, ProductID: productID, ReviewID: reviewID}
+	return m.intercept(ctx, call, func(ctx context.Context) error {
+		return m.next.UpdateProductReview(ctx, productID, reviewID, review)
+	})
+}
+
+func (m *intercepted) PatchProductReview(
+	ctx context.Context,
+	productID model.ID,
+	reviewID model.ID,
+	apply func(r *dto.Review) error,
+) (*dto.Review, error) {
+	var review *dto.Review
+	call := &Call{Method: "PatchProductReview", Kind: KindWrite, ProductID: productID, ReviewID: reviewID}
+	err := m.intercept(ctx, call, func(ctx context.Context) error {
+		var err error
+		review, err = m.next.PatchProductReview(ctx, productID, reviewID, apply)
+		return err
+	})
+	return review, err
+}
+
+func (m *intercepted) GetProductReview(ctx context.Context, productID model.ID, reviewID model.ID) (*dto.Review, error) {
+	var review *dto.Review
+	call := &Call{Method: "GetProductReview", Kind: KindRead, ProductID: productID, ReviewID: reviewID}
+	err := m.intercept(ctx, call, func(ctx context.Context) error {
+		var err error
+		review, err = m.next.GetProductReview(ctx, productID, reviewID)
+		return err
+	})
+	return review, err
+}
+
+func (m *intercepted) ListProductReviews(ctx context.Context, productID model.ID, offset int, limit int) ([]*dto.Review, error) {
+	var reviews []*dto.Review
+	err := m.intercept(ctx, &Call{Method: "ListProductReviews", Kind: KindRead, ProductID: productID}, func(ctx context.Context) error {
+		var err error
+		reviews, err = m.next.ListProductReviews(ctx, productID, offset, limit)
+		return err
+	})
+	return reviews, err
+}
+
+func (m *intercepted) ExportReviewerData(ctx context.Context, reviewer *dto.Reviewer) (*dto.ReviewerExport, error) {
+	var export *dto.ReviewerExport
+	err := m.intercept(ctx, &Call{Method: "ExportReviewerData", Kind: KindAdmin}, func(ctx context.Context) error {
+		var err error
+		export, err = m.next.ExportReviewerData(ctx, reviewer)
+		return err
+	})
+	return export, err
+}
+
+func (m *intercepted) EraseReviewerData(ctx context.Context, req *dto.ErasureRequest) (*dto.ErasureResult, error) {
+	var result *dto.ErasureResult
+	err := m.intercept(ctx, &Call{Method: "EraseReviewerData", Kind: KindAdmin}, func(ctx context.Context) error {
+		var err error
+		result, err = m.next.EraseReviewerData(ctx, req)
+		return err
+	})
+	return result, err
+}
+
+func (m *intercepted) WarmCache(ctx context.Context, req *dto.CacheWarmRequest) (*dto.CacheWarmResult, error) {
+	var result *dto.CacheWarmResult
+	err := m.intercept(ctx, &Call{Method: "WarmCache", Kind: KindAdmin}, func(ctx context.Context) error {
+		var err error
+		result, err = m.next.WarmCache(ctx, req)
+		return err
+	})
+	return result, err
+}
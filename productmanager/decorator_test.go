@@ -0,0 +1,137 @@
+package productmanager
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/dto"
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// deadlineManager records the deadline of GetProduct and WarmCache calls.
+type deadlineManager struct {
+	StubManager
+	hasDeadline bool
+}
+
+func (m *deadlineManager) GetProduct(ctx context.Context, productID model.ID) (*dto.ProductWithRating, error) {
+	_, m.hasDeadline = ctx.Deadline()
+	return m.StubManager.GetProduct(ctx, productID)
+}
+
+func (m *deadlineManager) WarmCache(ctx context.Context, req *dto.CacheWarmRequest) (*dto.CacheWarmResult, error) {
+	_, m.hasDeadline = ctx.Deadline()
+	return &dto.CacheWarmResult{}, nil
+}
+
+func TestDecorate_Order(t *testing.T) {
+	var order []string
+	trace := func(name string) Decorator {
+		return Intercept(func(ctx context.Context, call *Call, next func(ctx context.Context) error) error {
+			order = append(order, name+":"+call.Method)
+			return next(ctx)
+		})
+	}
+
+	m := Decorate(&StubManager{}, trace("outer"), trace("inner"))
+	_, err := m.GetProduct(t.Context(), 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"outer:GetProduct", "inner:GetProduct"}, order)
+}
+
+func TestTimeout(t *testing.T) {
+	next := &deadlineManager{}
+	m := Decorate(next, Timeout(time.Second))
+
+	_, err := m.GetProduct(t.Context(), 1)
+	require.NoError(t, err)
+	assert.True(t, next.hasDeadline)
+
+	_, err = m.WarmCache(t.Context(), &dto.CacheWarmRequest{})
+	require.NoError(t, err)
+	assert.False(t, next.hasDeadline, "admin calls are not bounded")
+}
+
+func TestAuthorization(t *testing.T) {
+	m := Decorate(&StubManager{}, Authorization(NewRoleAuthorizer([]string{"writer"}, []string{"admin"})))
+
+	writer := WithPrincipal(t.Context(), "writer")
+	admin := WithPrincipal(t.Context(), "admin")
+	claimed := events.WithActor(t.Context(), "admin")
+
+	_, err := m.GetProduct(t.Context(), 1)
+	assert.NoError(t, err, "everyone may read")
+
+	_, err = m.CreateProduct(t.Context(), &dto.Product{})
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = m.CreateProduct(claimed, &dto.Product{})
+	assert.ErrorIs(t, err, ErrForbidden, "the actor is only an audit label")
+
+	_, err = m.CreateProduct(writer, &dto.Product{})
+	assert.NoError(t, err)
+
+	_, err = m.CreateProduct(admin, &dto.Product{})
+	assert.NoError(t, err)
+
+	_, err = m.WarmCache(writer, &dto.CacheWarmRequest{})
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = m.WarmCache(admin, &dto.CacheWarmRequest{})
+	assert.NoError(t, err)
+}
+
+func TestMetrics(t *testing.T) {
+	m := Decorate(&StubManager{}, Metrics())
+
+	_, err := m.GetProduct(t.Context(), 1)
+	require.NoError(t, err)
+	err = m.UpdateProduct(t.Context(), 1, &dto.Product{})
+	require.Error(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(calls.WithLabelValues("GetProduct", "ok")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(calls.WithLabelValues("UpdateProduct", "error")))
+}
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	m := Decorate(&StubManager{}, Tracing(provider.Tracer("test")))
+
+	_, err := m.GetProduct(t.Context(), 1)
+	require.NoError(t, err)
+	err = m.DeleteProduct(t.Context(), 1)
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "productmanager.GetProduct", spans[0].Name())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, "productmanager.DeleteProduct", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
+
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	m := Decorate(&StubManager{}, Logging(&logger))
+
+	err := m.DeleteProduct(t.Context(), 1)
+	require.Error(t, err)
+
+	assert.Contains(t, buf.String(), `"method":"DeleteProduct"`)
+	assert.Contains(t, buf.String(), `"level":"warn"`)
+}
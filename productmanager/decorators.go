@@ -0,0 +1,101 @@
+package productmanager
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	calls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "manager_calls_total",
+		Help: "Number of Manager calls by method and result.",
+	}, []string{"method", "result"})
+
+	callDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "manager_call_duration_seconds",
+		Help:    "Latency of Manager calls by method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+// Logging logs every call with its duration, failed calls as warnings.
+func Logging(logger *zerolog.Logger) Decorator {
+	return Intercept(func(ctx context.Context, call *Call, next func(ctx context.Context) error) error {
+		start := time.Now()
+		err := next(ctx)
+
+		event := logger.Debug()
+		if err != nil {
+			event = logger.Warn().Err(err)
+		}
+
+		event.
+			Str("method", call.Method).
+			Int("product", call.ProductID).
+			Int("review", call.ReviewID).
+			Dur("duration", time.Since(start)).
+			Msg("Manager call")
+
+		return err
+	})
+}
+
+// Metrics counts calls and observes their latency.
+func Metrics() Decorator {
+	return Intercept(func(ctx context.Context, call *Call, next func(ctx context.Context) error) error {
+		start := time.Now()
+		err := next(ctx)
+
+		result := "ok"
+		if err != nil {
+			result = "error"
+		}
+
+		calls.WithLabelValues(call.Method, result).Inc()
+		callDuration.WithLabelValues(call.Method).Observe(time.Since(start).Seconds())
+
+		return err
+	})
+}
+
+// Tracing starts a span for every call.
+func Tracing(tracer trace.Tracer) Decorator {
+	return Intercept(func(ctx context.Context, call *Call, next func(ctx context.Context) error) error {
+		ctx, span := tracer.Start(ctx, "productmanager."+call.Method, trace.WithAttributes(
+			attribute.String("manager.kind", string(call.Kind)),
+			attribute.Int("product.id", call.ProductID),
+			attribute.Int("review.id", call.ReviewID),
+		))
+		defer span.End()
+
+		err := next(ctx)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+
+		return err
+	})
+}
+
+// Timeout bounds read and write calls. Admin calls work in bulk and are
+// bounded by their caller only.
+func Timeout(timeout time.Duration) Decorator {
+	return Intercept(func(ctx context.Context, call *Call, next func(ctx context.Context) error) error {
+		if call.Kind == KindAdmin {
+			return next(ctx)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		return next(ctx)
+	})
+}
@@ -11,14 +11,30 @@ type Manager interface {
 	CreateProduct(ctx context.Context, p *dto.Product) (model.ID, error)
 	UpdateProduct(ctx context.Context, productID model.ID, p *dto.Product) error
 	DeleteProduct(ctx context.Context, productID model.ID) error
+	// PatchProduct lets apply change the stored product and updates the changed fields.
+	// It returns nil if the product doesn't exist.
+	PatchProduct(ctx context.Context, productID model.ID, apply func(p *dto.Product) error) (*dto.Product, error)
 
 	GetProduct(ctx context.Context, productID model.ID) (*dto.ProductWithRating, error)
 	ListProducts(ctx context.Context, offset int, limit int) ([]*dto.ProductWithRating, error)
 
 	CreateProductReview(ctx context.Context, productID model.ID, r *dto.Review) (model.ID, error)
+	// CreateProductReviews creates valid reviews of any products in one transaction.
+	// It returns an item with the created ID or not_found for every review, in order.
+	CreateProductReviews(ctx context.Context, reviews []*dto.BatchReview) ([]*dto.ReviewBatchItem, error)
+	// DeleteProductReview and UpdateProductReview do nothing if the review
+	// doesn't exist or belongs to another product.
 	DeleteProductReview(ctx context.Context, productID model.ID, reviewID model.ID) error
 	UpdateProductReview(ctx context.Context, productID model.ID, reviewID model.ID, review *dto.Review) error
+	// PatchProductReview lets apply change the stored review and updates the changed fields.
+	// It returns nil if the review doesn't exist.
+	PatchProductReview(ctx context.Context, productID model.ID, reviewID model.ID, apply func(r *dto.Review) error) (*dto.Review, error)
 
 	GetProductReview(ctx context.Context, productID model.ID, reviewID model.ID) (*dto.Review, error)
 	ListProductReviews(ctx context.Context, productID model.ID, offset int, limit int) ([]*dto.Review, error)
+
+	ExportReviewerData(ctx context.Context, reviewer *dto.Reviewer) (*dto.ReviewerExport, error)
+	EraseReviewerData(ctx context.Context, req *dto.ErasureRequest) (*dto.ErasureResult, error)
+
+	WarmCache(ctx context.Context, req *dto.CacheWarmRequest) (*dto.CacheWarmResult, error)
 }
@@ -0,0 +1,158 @@
+package productmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/cache"
+	"github.com/lameaux/golang-product-reviews/lock"
+	"github.com/lameaux/golang-product-reviews/model"
+)
+
+const (
+	loadTimeout    = 10 * time.Second
+	refreshTimeout = 10 * time.Second
+)
+
+// cachedValue describes how one value is read from the cache and loaded on a miss.
+type cachedValue[T any] struct {
+	// key identifies the value for background refreshes.
+	key string
+	// lockID is the single-flight lock taken while loading.
+	lockID     model.ID
+	get        func(ctx context.Context) (T, error)
+	generation func(ctx context.Context) (cache.Generation, error)
+	load       func(ctx context.Context) (T, error)
+	set        func(ctx context.Context, load cache.Load, value T)
+}
+
+// readThrough serves a value from the cache and loads it with single flight on a miss.
+// Stale values are served while one background refresh reloads them.
+func readThrough[T any](ctx context.Context, m *DAOManager, v cachedValue[T]) (T, error) {
+	value, err := v.get(ctx)
+	switch {
+	case err == nil:
+		return value, nil
+	case errors.Is(err, cache.Stale):
+		refreshInBackground(ctx, m, v)
+		return value, nil
+	case !errors.Is(err, cache.NotFound):
+		return value, err
+	}
+
+	return coalesce(ctx, m, v)
+}
+
+// coalesce shares one load between concurrent callers of this instance,
+// they get the value as soon as it is loaded. The load outlives a canceled
+// caller, since others may wait for it.
+func coalesce[T any](ctx context.Context, m *DAOManager, v cachedValue[T]) (T, error) {
+	// the deadline of the caller still bounds the wait for the lock
+	deadline := time.Now().Add(loadTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	ch := m.loads.DoChan(v.key, func() (any, error) {
+		ctx, cancel := context.WithDeadline(context.WithoutCancel(ctx), deadline)
+		defer cancel()
+
+		return lockAndLoad(ctx, m, v)
+	})
+
+	select {
+	case <-ctx.Done():
+		var value T
+		return value, ctx.Err()
+	case res := <-ch:
+		// a nil value is a nil interface
+		value, _ := res.Val.(T)
+		return value, res.Err
+	}
+}
+
+// lockAndLoad takes the single-flight lock, which keeps other replicas from loading the value too.
+func lockAndLoad[T any](ctx context.Context, m *DAOManager, v cachedValue[T]) (T, error) {
+	var value T
+
+	lease, err := m.lock.Lock(ctx, v.lockID)
+	if err != nil {
+		return value, fmt.Errorf("lock.Lock: %w", err)
+	}
+	defer release(ctx, lease)
+
+	// check again after obtaining lock, a stale value is good enough here
+	value, err = v.get(ctx)
+	if err == nil || errors.Is(err, cache.Stale) {
+		return value, nil
+	}
+	if !errors.Is(err, cache.NotFound) {
+		return value, err
+	}
+
+	return loadAndSet(ctx, lease, v)
+}
+
+// release frees the lock even if ctx is canceled, otherwise it is held until it expires.
+func release(ctx context.Context, lease lock.Lease) {
+	_ = lease.Release(context.WithoutCancel(ctx))
+}
+
+func loadAndSet[T any](ctx context.Context, lease lock.Lease, v cachedValue[T]) (T, error) {
+	var value T
+
+	// read before loading, a concurrent write makes the loaded value unreachable
+	gen, err := v.generation(ctx)
+	if err != nil {
+		return value, fmt.Errorf("cache generation: %w", err)
+	}
+
+	start := time.Now()
+	value, err = v.load(ctx)
+	if err != nil {
+		return value, err
+	}
+
+	// another loader may hold the lock now, skip the write early.
+	// The lease can still be lost before the write, the fence makes the cache reject it.
+	select {
+	case <-lease.Lost():
+		return value, nil
+	default:
+	}
+
+	v.set(ctx, cache.Load{Generation: gen, Duration: time.Since(start), Fence: lease.Token()}, value)
+
+	return value, nil
+}
+
+// refreshInBackground starts a refresh unless this instance is already
+// refreshing the value. The lock keeps other replicas from refreshing it too.
+// Failures are ignored, the next reader tries again.
+func refreshInBackground[T any](ctx context.Context, m *DAOManager, v cachedValue[T]) {
+	if _, busy := m.refreshing.LoadOrStore(v.key, struct{}{}); busy {
+		return
+	}
+
+	go func() {
+		defer m.refreshing.Delete(v.key)
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), refreshTimeout)
+		defer cancel()
+
+		lease, err := m.lock.Lock(ctx, v.lockID)
+		if err != nil {
+			return
+		}
+		defer release(ctx, lease)
+
+		// another replica may have refreshed it while we waited for the lock
+		if _, err := v.get(ctx); err == nil {
+			return
+		}
+
+		_, _ = loadAndSet(ctx, lease, v)
+	}()
+}
@@ -5,11 +5,15 @@ import (
 	"errors"
 
 	"github.com/lameaux/golang-product-reviews/dto"
+	"github.com/lameaux/golang-product-reviews/lock"
 	"github.com/lameaux/golang-product-reviews/model"
 )
 
 var _ Manager = (*StubManager)(nil)
 
+// StubLockedID is a product whose lock is always contended.
+const StubLockedID model.ID = 503
+
 type StubManager struct {
 	Products []*dto.ProductWithRating
 	Reviews  []*dto.Review
@@ -27,6 +31,19 @@ func (s *StubManager) UpdateProduct(ctx context.Context, productID model.ID, p *
 	return nil
 }
 
+func (s *StubManager) PatchProduct(ctx context.Context, productID model.ID, apply func(p *dto.Product) error) (*dto.Product, error) {
+	if productID > len(s.Products) {
+		return nil, nil
+	}
+
+	p := s.Products[productID-1].Product
+	if err := apply(&p); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
 func (s *StubManager) DeleteProduct(ctx context.Context, productID model.ID) error {
 	if productID > len(s.Products) {
 		return errors.New("not found")
@@ -36,6 +53,10 @@ func (s *StubManager) DeleteProduct(ctx context.Context, productID model.ID) err
 }
 
 func (s *StubManager) GetProduct(ctx context.Context, productID model.ID) (*dto.ProductWithRating, error) {
+	if productID == StubLockedID {
+		return nil, lock.ErrLocked
+	}
+
 	if productID > len(s.Products) {
 		return nil, nil
 	}
@@ -51,6 +72,20 @@ func (s *StubManager) CreateProductReview(ctx context.Context, productID model.I
 	return len(s.Reviews) + 1, nil
 }
 
+func (s *StubManager) CreateProductReviews(ctx context.Context, reviews []*dto.BatchReview) ([]*dto.ReviewBatchItem, error) {
+	items := make([]*dto.ReviewBatchItem, 0, len(reviews))
+	for i, review := range reviews {
+		item := &dto.ReviewBatchItem{Index: i, ProductID: review.ProductID, Status: dto.BatchItemNotFound}
+		if review.ProductID <= len(s.Products) {
+			item.Status = dto.BatchItemCreated
+			item.ID = len(s.Reviews) + i + 1
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
 func (s *StubManager) DeleteProductReview(ctx context.Context, productID model.ID, reviewID model.ID) error {
 	if productID > len(s.Products) {
 		return errors.New("not found")
@@ -75,6 +110,24 @@ func (s *StubManager) UpdateProductReview(ctx context.Context, productID model.I
 	return nil
 }
 
+func (s *StubManager) PatchProductReview(
+	ctx context.Context,
+	productID model.ID,
+	reviewID model.ID,
+	apply func(r *dto.Review) error,
+) (*dto.Review, error) {
+	if productID > len(s.Products) || reviewID > len(s.Reviews) {
+		return nil, nil
+	}
+
+	r := *s.Reviews[reviewID-1]
+	if err := apply(&r); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
 func (s *StubManager) GetProductReview(ctx context.Context, productID model.ID, reviewID model.ID) (*dto.Review, error) {
 	if productID > len(s.Products) {
 		return nil, nil
@@ -90,3 +143,29 @@ func (s *StubManager) GetProductReview(ctx context.Context, productID model.ID,
 func (s *StubManager) ListProductReviews(ctx context.Context, productID model.ID, offset int, limit int) ([]*dto.Review, error) {
 	return s.Reviews, nil
 }
+
+func (s *StubManager) ExportReviewerData(ctx context.Context, reviewer *dto.Reviewer) (*dto.ReviewerExport, error) {
+	result := &dto.ReviewerExport{Reviewer: *reviewer, Reviews: []*dto.ReviewerReview{}}
+	for _, review := range s.Reviews {
+		if review.FirstName == reviewer.FirstName && review.LastName == reviewer.LastName {
+			result.Reviews = append(result.Reviews, &dto.ReviewerReview{ProductID: 1, Review: *review})
+		}
+	}
+
+	return result, nil
+}
+
+func (s *StubManager) EraseReviewerData(ctx context.Context, req *dto.ErasureRequest) (*dto.ErasureResult, error) {
+	count := 0
+	for _, review := range s.Reviews {
+		if review.FirstName == req.FirstName && review.LastName == req.LastName {
+			count++
+		}
+	}
+
+	return &dto.ErasureResult{Mode: req.Mode, Reviews: count}, nil
+}
+
+func (s *StubManager) WarmCache(ctx context.Context, req *dto.CacheWarmRequest) (*dto.CacheWarmResult, error) {
+	return &dto.CacheWarmResult{By: req.By, Products: len(s.Products)}, nil
+}
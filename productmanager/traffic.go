@@ -0,0 +1,82 @@
+package productmanager
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/model"
+)
+
+const trafficWindow = 10 * time.Minute
+
+// traffic counts product reads of this replica in the current and the
+// previous window, so the top products reflect recent traffic.
+type traffic struct {
+	mu       sync.Mutex
+	current  map[model.ID]int
+	previous map[model.ID]int
+	rotateAt time.Time
+	now      func() time.Time
+}
+
+func newTraffic() *traffic {
+	return &traffic{
+		current:  make(map[model.ID]int),
+		previous: make(map[model.ID]int),
+		now:      time.Now,
+	}
+}
+
+func (t *traffic) record(productID model.ID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rotate()
+	t.current[productID]++
+}
+
+// top returns up to limit products, the most read first.
+func (t *traffic) top(limit int) []model.ID {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rotate()
+
+	counts := make(map[model.ID]int, len(t.current)+len(t.previous))
+	for id, n := range t.previous {
+		counts[id] += n
+	}
+	for id, n := range t.current {
+		counts[id] += n
+	}
+
+	ids := make([]model.ID, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b model.ID) int {
+		if counts[a] != counts[b] {
+			return counts[b] - counts[a]
+		}
+		return a - b
+	})
+
+	return ids[:min(limit, len(ids))]
+}
+
+func (t *traffic) rotate() {
+	now := t.now()
+	if now.Before(t.rotateAt) {
+		return
+	}
+
+	// a window without reads drops both
+	if now.Sub(t.rotateAt) < trafficWindow {
+		t.previous = t.current
+	} else {
+		t.previous = make(map[model.ID]int)
+	}
+	t.current = make(map[model.ID]int)
+	t.rotateAt = now.Add(trafficWindow)
+}
@@ -0,0 +1,103 @@
+package productmanager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/lameaux/golang-product-reviews/dto"
+	"github.com/lameaux/golang-product-reviews/model"
+)
+
+const (
+	WarmByReviews = "reviews"
+	WarmByTraffic = "traffic"
+
+	DefaultWarmLimit       = 100
+	DefaultWarmConcurrency = 8
+)
+
+// warmReviewsLimit is the default page size of the reviews API.
+const warmReviewsLimit = 100
+
+// WarmCache preloads the product, its rating and the first review page of the
+// top products. Values are loaded through the cache like on reads, so they
+// take the single-flight lock and values already cached are not reloaded.
+func (m *DAOManager) WarmCache(ctx context.Context, req *dto.CacheWarmRequest) (*dto.CacheWarmResult, error) {
+	by, limit, concurrency := req.By, req.Limit, req.Concurrency
+	if by == "" {
+		by = WarmByReviews
+	}
+	if limit == 0 {
+		limit = DefaultWarmLimit
+	}
+	if concurrency == 0 {
+		concurrency = DefaultWarmConcurrency
+	}
+
+	var productIDs []model.ID
+	switch by {
+	case WarmByReviews:
+		var err error
+		if productIDs, err = m.dao.TopReviewedProducts(ctx, limit); err != nil {
+			return nil, fmt.Errorf("dao.TopReviewedProducts: %w", err)
+		}
+	case WarmByTraffic:
+		productIDs = m.traffic.top(limit)
+	default:
+		return nil, fmt.Errorf("unknown warm-up order: %q", by)
+	}
+
+	var failed atomic.Int64
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+loop:
+	for _, productID := range productIDs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break loop
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := m.warmProduct(ctx, productID); err != nil {
+				failed.Add(1)
+				m.logger.Warn().Err(err).Int("product", productID).Msg("cache warm-up failed")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("warm-up canceled: %w", err)
+	}
+
+	return &dto.CacheWarmResult{By: by, Products: len(productIDs), Failed: int(failed.Load())}, nil
+}
+
+func (m *DAOManager) warmProduct(ctx context.Context, productID model.ID) error {
+	product, err := m.getProduct(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("getProduct: %w", err)
+	}
+
+	if product == nil {
+		return nil
+	}
+
+	if _, err := m.getProductRating(ctx, productID); err != nil {
+		return fmt.Errorf("getProductRating: %w", err)
+	}
+
+	if _, err := m.ListProductReviews(ctx, productID, 0, warmReviewsLimit); err != nil {
+		return fmt.Errorf("ListProductReviews: %w", err)
+	}
+
+	return nil
+}
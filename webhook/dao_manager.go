@@ -0,0 +1,137 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/database"
+	"github.com/lameaux/golang-product-reviews/dto"
+	"github.com/lameaux/golang-product-reviews/model"
+)
+
+var _ Manager = (*DAOManager)(nil)
+
+type DAOManager struct {
+	dao database.WebhookDAO
+}
+
+func NewManager(dao database.WebhookDAO) *DAOManager {
+	return &DAOManager{dao: dao}
+}
+
+func (m *DAOManager) CreateWebhook(ctx context.Context, w *dto.Webhook) (model.ID, error) {
+	webhook := &model.Webhook{
+		URL:       w.URL,
+		Events:    strings.Join(w.Events, ","),
+		Secret:    w.Secret,
+		Paused:    w.Paused,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	webhookID, err := m.dao.CreateWebhook(ctx, webhook)
+	if err != nil {
+		return 0, fmt.Errorf("dao.CreateWebhook: %w", err)
+	}
+
+	return webhookID, nil
+}
+
+func (m *DAOManager) UpdateWebhook(ctx context.Context, webhookID model.ID, w *dto.Webhook) error {
+	webhook := &model.Webhook{
+		ID:     webhookID,
+		URL:    w.URL,
+		Events: strings.Join(w.Events, ","),
+		Secret: w.Secret,
+		Paused: w.Paused,
+	}
+
+	if err := m.dao.UpdateWebhook(ctx, webhook); err != nil {
+		return fmt.Errorf("dao.UpdateWebhook: %w", err)
+	}
+
+	return nil
+}
+
+func (m *DAOManager) DeleteWebhook(ctx context.Context, webhookID model.ID) error {
+	if err := m.dao.DeleteWebhook(ctx, webhookID); err != nil {
+		return fmt.Errorf("dao.DeleteWebhook: %w", err)
+	}
+
+	return nil
+}
+
+func (m *DAOManager) GetWebhook(ctx context.Context, webhookID model.ID) (*dto.Webhook, error) {
+	webhook, err := m.dao.GetWebhook(ctx, webhookID)
+	if err != nil {
+		return nil, fmt.Errorf("dao.GetWebhook: %w", err)
+	}
+
+	if webhook == nil {
+		return nil, nil
+	}
+
+	return convertWebhook(webhook), nil
+}
+
+func (m *DAOManager) ListWebhooks(ctx context.Context, offset int, limit int) ([]*dto.Webhook, error) {
+	webhooks, err := m.dao.ListWebhooks(ctx, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("dao.ListWebhooks: %w", err)
+	}
+
+	result := make([]*dto.Webhook, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		result = append(result, convertWebhook(webhook))
+	}
+
+	return result, nil
+}
+
+func (m *DAOManager) ListDeliveries(ctx context.Context, webhookID model.ID, offset int, limit int) ([]*dto.WebhookDelivery, error) {
+	deliveries, err := m.dao.ListWebhookDeliveries(ctx, webhookID, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("dao.ListWebhookDeliveries: %w", err)
+	}
+
+	result := make([]*dto.WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		result = append(result, convertDelivery(delivery))
+	}
+
+	return result, nil
+}
+
+// convertWebhook never exposes the secret.
+func convertWebhook(webhook *model.Webhook) *dto.Webhook {
+	return &dto.Webhook{
+		ID:     webhook.ID,
+		URL:    webhook.URL,
+		Events: splitEvents(webhook.Events),
+		Paused: webhook.Paused,
+	}
+}
+
+func convertDelivery(delivery *model.WebhookDelivery) *dto.WebhookDelivery {
+	return &dto.WebhookDelivery{
+		ID:             delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		EventID:        delivery.EventID,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	}
+}
+
+func splitEvents(events string) []string {
+	if events == "" {
+		return []string{}
+	}
+
+	return strings.Split(events, ",")
+}
@@ -0,0 +1,258 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/database"
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/lameaux/golang-product-reviews/notifier"
+	"github.com/rs/zerolog"
+)
+
+var _ notifier.Notifier = (*Dispatcher)(nil)
+
+const (
+	EventIDHeader   = "X-Event-ID"
+	WebhookIDHeader = "X-Webhook-ID"
+	AttemptHeader   = "X-Delivery-Attempt"
+)
+
+const maxErrorLength = 512
+
+type Config struct {
+	// MaxAttempts is the number of failed attempts before a delivery is dead-lettered.
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	Timeout      time.Duration
+	PollInterval time.Duration
+	BatchSize    int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		MaxAttempts:  8,
+		BaseBackoff:  time.Second,
+		MaxBackoff:   time.Hour,
+		Timeout:      10 * time.Second,
+		PollInterval: time.Second,
+		BatchSize:    50,
+	}
+}
+
+// Dispatcher turns review events into webhook deliveries and sends them.
+// Deliveries are stored first, so they survive restarts and are retried
+// with exponential backoff until they succeed or are dead-lettered.
+type Dispatcher struct {
+	logger *zerolog.Logger
+	dao    database.WebhookDAO
+	client *http.Client
+	cfg    Config
+}
+
+func NewDispatcher(logger *zerolog.Logger, dao database.WebhookDAO, cfg Config) *Dispatcher {
+	return &Dispatcher{
+		logger: logger,
+		dao:    dao,
+		client: &http.Client{Timeout: cfg.Timeout},
+		cfg:    cfg,
+	}
+}
+
+// Notify enqueues a delivery for every active webhook subscribed to the event.
+func (d *Dispatcher) Notify(ctx context.Context, event *events.Event) {
+	payload, err := events.Encode(event)
+	if err != nil {
+		d.logger.Error().Err(err).Str("id", event.ID).Msg("failed to encode event")
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+
+	webhooks, err := d.dao.ListActiveWebhooks(ctx)
+	if err != nil {
+		d.logger.Error().Err(err).Str("id", event.ID).Msg("failed to list webhooks")
+		return
+	}
+
+	now := time.Now().UTC()
+
+	var deliveries []*model.WebhookDelivery
+	for _, webhook := range webhooks {
+		if !subscribed(webhook, event) {
+			continue
+		}
+
+		deliveries = append(deliveries, &model.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			Payload:       string(payload),
+			Status:        model.DeliveryStatusPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+	}
+
+	if err := d.dao.CreateWebhookDeliveries(ctx, deliveries); err != nil {
+		d.logger.Error().Err(err).Str("id", event.ID).Msg("failed to enqueue webhook deliveries")
+		return
+	}
+
+	d.logger.Debug().Str("id", event.ID).Int("deliveries", len(deliveries)).Msg("webhook deliveries enqueued")
+}
+
+// subscribed matches the webhook filter, a list of actions or subject patterns.
+func subscribed(webhook *model.Webhook, event *events.Event) bool {
+	filters := splitEvents(webhook.Events)
+	return len(filters) == 0 || slices.ContainsFunc(filters, func(filter string) bool {
+		return events.MatchFilter(filter, event)
+	})
+}
+
+// Run sends due deliveries until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			d.DispatchDue(ctx)
+		}
+	}
+}
+
+// DispatchDue sends one batch of due deliveries and returns its size.
+func (d *Dispatcher) DispatchDue(ctx context.Context) int {
+	now := time.Now().UTC()
+
+	// the lease expires if this instance dies mid-delivery, then another one retries
+	deliveries, err := d.dao.ClaimDueWebhookDeliveries(ctx, now, now.Add(2*d.cfg.Timeout), d.cfg.BatchSize)
+	if err != nil {
+		d.logger.Error().Err(err).Msg("failed to claim webhook deliveries")
+		return 0
+	}
+
+	webhooks := make(map[model.ID]*model.Webhook)
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			webhook, err = d.dao.GetWebhook(ctx, delivery.WebhookID)
+			if err != nil {
+				d.logger.Error().Err(err).Int("webhook", delivery.WebhookID).Msg("failed to get webhook")
+				continue
+			}
+			webhooks[delivery.WebhookID] = webhook
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.deliver(ctx, webhook, delivery)
+		}()
+	}
+	wg.Wait()
+
+	return len(deliveries)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) {
+	now := time.Now().UTC()
+	delivery.UpdatedAt = now
+
+	switch {
+	case webhook == nil:
+		delivery.Status = model.DeliveryStatusDead
+		delivery.LastError = "webhook deleted"
+	case webhook.Paused:
+		// paused after the claim, the lease expires and the delivery isn't claimed until resumed
+		return
+	default:
+		delivery.Attempts++
+		statusCode, err := d.send(ctx, webhook, delivery)
+		d.recordAttempt(delivery, statusCode, err, now)
+	}
+
+	if err := d.dao.UpdateWebhookDelivery(ctx, delivery); err != nil {
+		d.logger.Error().Err(err).Int("delivery", delivery.ID).Msg("failed to update webhook delivery")
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("new request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, body))
+	req.Header.Set(EventIDHeader, delivery.EventID)
+	req.Header.Set(WebhookIDHeader, strconv.Itoa(webhook.ID))
+	req.Header.Set(AttemptHeader, strconv.Itoa(delivery.Attempts))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// drain, so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+func (d *Dispatcher) recordAttempt(delivery *model.WebhookDelivery, statusCode int, err error, now time.Time) {
+	delivery.LastStatusCode = statusCode
+
+	if err == nil {
+		delivery.Status = model.DeliveryStatusDelivered
+		delivery.LastError = ""
+		d.logger.Debug().Int("delivery", delivery.ID).Int("attempts", delivery.Attempts).Msg("webhook delivered")
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if len(delivery.LastError) > maxErrorLength {
+		delivery.LastError = delivery.LastError[:maxErrorLength]
+	}
+
+	if delivery.Attempts >= d.cfg.MaxAttempts {
+		delivery.Status = model.DeliveryStatusDead
+		d.logger.Warn().Err(err).Int("delivery", delivery.ID).Int("attempts", delivery.Attempts).Msg("webhook delivery dead-lettered")
+		return
+	}
+
+	delivery.NextAttemptAt = now.Add(d.backoff(delivery.Attempts))
+	d.logger.Debug().Err(err).Int("delivery", delivery.ID).Time("next", delivery.NextAttemptAt).Msg("webhook delivery failed")
+}
+
+// backoff doubles the delay after every failed attempt, up to MaxBackoff.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.BaseBackoff
+	for i := 1; i < attempts && delay < d.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, d.cfg.MaxBackoff)
+}
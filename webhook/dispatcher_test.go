@@ -0,0 +1,230 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lameaux/golang-product-reviews/database"
+	"github.com/lameaux/golang-product-reviews/events"
+	"github.com/lameaux/golang-product-reviews/model"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryDAO is a minimal in-memory database.WebhookDAO.
+type memoryDAO struct {
+	database.WebhookDAO
+
+	mu         sync.Mutex
+	webhooks   []*model.Webhook
+	deliveries []*model.WebhookDelivery
+}
+
+func (m *memoryDAO) ListActiveWebhooks(context.Context) ([]*model.Webhook, error) {
+	var result []*model.Webhook
+	for _, webhook := range m.webhooks {
+		if !webhook.Paused {
+			result = append(result, webhook)
+		}
+	}
+	return result, nil
+}
+
+func (m *memoryDAO) GetWebhook(_ context.Context, id model.ID) (*model.Webhook, error) {
+	for _, webhook := range m.webhooks {
+		if webhook.ID == id {
+			return webhook, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryDAO) CreateWebhookDeliveries(_ context.Context, deliveries []*model.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, delivery := range deliveries {
+		delivery.ID = len(m.deliveries) + 1
+		m.deliveries = append(m.deliveries, delivery)
+	}
+	return nil
+}
+
+func (m *memoryDAO) ClaimDueWebhookDeliveries(_ context.Context, now time.Time, leaseUntil time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var result []*model.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if webhook, _ := m.GetWebhook(context.Background(), delivery.WebhookID); webhook != nil && webhook.Paused {
+			continue
+		}
+
+		if delivery.Status == model.DeliveryStatusPending && !delivery.NextAttemptAt.After(now) && len(result) < limit {
+			delivery.NextAttemptAt = leaseUntil
+			claimed := *delivery
+			result = append(result, &claimed)
+		}
+	}
+	return result, nil
+}
+
+func (m *memoryDAO) UpdateWebhookDelivery(_ context.Context, delivery *model.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	updated := *delivery
+	m.deliveries[delivery.ID-1] = &updated
+	return nil
+}
+
+func (m *memoryDAO) delivery(id model.ID) model.WebhookDelivery {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return *m.deliveries[id-1]
+}
+
+func testConfig() Config {
+	cfg := DefaultConfig()
+	cfg.MaxAttempts = 3
+	cfg.BaseBackoff = 0
+	return cfg
+}
+
+func TestDispatcher_Deliver(t *testing.T) {
+	const secret = "0123456789abcdef"
+
+	var received atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		assert.True(t, Verify(secret, body, r.Header.Get(SignatureHeader)))
+		assert.Equal(t, "1", r.Header.Get(AttemptHeader))
+
+		event, err := events.Decode(body)
+		assert.NoError(t, err)
+		assert.Equal(t, event.ID, r.Header.Get(EventIDHeader))
+
+		received.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	dao := &memoryDAO{webhooks: []*model.Webhook{
+		{ID: 1, URL: receiver.URL, Secret: secret, Events: "create"},
+		{ID: 2, URL: receiver.URL, Secret: secret, Events: "delete"},
+		{ID: 3, URL: receiver.URL, Secret: secret, Paused: true},
+		{ID: 4, URL: receiver.URL, Secret: secret, Events: "products.*.updated,products.1.reviews.*.deleted"},
+	}}
+
+	d := NewDispatcher(&log.Logger, dao, testConfig())
+	d.Notify(t.Context(), events.New(t.Context(), events.ActionCreate, 1, 2))
+
+	require.Len(t, dao.deliveries, 1)
+	assert.Equal(t, 1, d.DispatchDue(t.Context()))
+
+	delivery := dao.delivery(1)
+	assert.Equal(t, model.DeliveryStatusDelivered, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusNoContent, delivery.LastStatusCode)
+	assert.Equal(t, int32(1), received.Load())
+
+	assert.Equal(t, 0, d.DispatchDue(t.Context()))
+}
+
+func TestDispatcher_RetryAndDeadLetter(t *testing.T) {
+	var received atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	dao := &memoryDAO{webhooks: []*model.Webhook{
+		{ID: 1, URL: receiver.URL, Secret: "0123456789abcdef"},
+	}}
+
+	d := NewDispatcher(&log.Logger, dao, testConfig())
+	d.Notify(t.Context(), events.New(t.Context(), events.ActionUpdate, 1, 2))
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		assert.Equal(t, 1, d.DispatchDue(t.Context()))
+
+		delivery := dao.delivery(1)
+		assert.Equal(t, attempt, delivery.Attempts)
+		assert.Equal(t, http.StatusServiceUnavailable, delivery.LastStatusCode)
+		assert.Contains(t, delivery.LastError, "unexpected status")
+	}
+
+	assert.Equal(t, model.DeliveryStatusDead, dao.delivery(1).Status)
+	assert.Equal(t, 0, d.DispatchDue(t.Context()))
+	assert.Equal(t, int32(3), received.Load())
+}
+
+func TestDispatcher_Paused(t *testing.T) {
+	var received atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	webhook := &model.Webhook{ID: 1, URL: receiver.URL, Secret: "0123456789abcdef"}
+	dao := &memoryDAO{webhooks: []*model.Webhook{webhook}}
+
+	d := NewDispatcher(&log.Logger, dao, testConfig())
+	d.Notify(t.Context(), events.New(t.Context(), events.ActionCreate, 1, 2))
+
+	webhook.Paused = true
+	assert.Equal(t, 0, d.DispatchDue(t.Context()))
+	assert.Equal(t, model.DeliveryStatusPending, dao.delivery(1).Status)
+
+	// queued deliveries are sent right after the webhook is resumed
+	webhook.Paused = false
+	assert.Equal(t, 1, d.DispatchDue(t.Context()))
+	assert.Equal(t, model.DeliveryStatusDelivered, dao.delivery(1).Status)
+	assert.Equal(t, int32(1), received.Load())
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	d := NewDispatcher(&log.Logger, &memoryDAO{}, Config{BaseBackoff: time.Second, MaxBackoff: time.Minute})
+
+	assert.Equal(t, time.Second, d.backoff(1))
+	assert.Equal(t, 2*time.Second, d.backoff(2))
+	assert.Equal(t, 8*time.Second, d.backoff(4))
+	assert.Equal(t, time.Minute, d.backoff(10))
+}
+
+func TestSign(t *testing.T) {
+	signature := Sign("secret", []byte(`{"id":"e1"}`))
+
+	assert.Equal(t, "sha256=", signature[:7])
+	assert.True(t, Verify("secret", []byte(`{"id":"e1"}`), signature))
+	assert.False(t, Verify("other", []byte(`{"id":"e1"}`), signature))
+	assert.False(t, Verify("secret", []byte(`{"id":"e2"}`), signature))
+}
+
+func TestDispatcher_SubjectFilter(t *testing.T) {
+	dao := &memoryDAO{webhooks: []*model.Webhook{
+		{ID: 1, URL: "http://localhost", Secret: "0123456789abcdef", Events: "products.*.updated"},
+		{ID: 2, URL: "http://localhost", Secret: "0123456789abcdef", Events: "products.1.reviews.>"},
+	}}
+
+	d := NewDispatcher(&log.Logger, dao, testConfig())
+	d.Notify(t.Context(), events.New(t.Context(), events.ActionUpdate, 1, 0))
+	d.Notify(t.Context(), events.New(t.Context(), events.ActionUpdate, 1, 2))
+	d.Notify(t.Context(), events.New(t.Context(), events.ActionUpdate, 2, 3))
+
+	require.Len(t, dao.deliveries, 2)
+	assert.Equal(t, 1, dao.deliveries[0].WebhookID)
+	assert.Equal(t, 2, dao.deliveries[1].WebhookID)
+}
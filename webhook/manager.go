@@ -0,0 +1,23 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/lameaux/golang-product-reviews/database"
+	"github.com/lameaux/golang-product-reviews/dto"
+	"github.com/lameaux/golang-product-reviews/model"
+)
+
+// ErrNotFound is returned by UpdateWebhook and DeleteWebhook for webhooks that don't exist.
+var ErrNotFound = database.ErrNotFound
+
+type Manager interface {
+	CreateWebhook(ctx context.Context, w *dto.Webhook) (model.ID, error)
+	UpdateWebhook(ctx context.Context, webhookID model.ID, w *dto.Webhook) error
+	DeleteWebhook(ctx context.Context, webhookID model.ID) error
+
+	GetWebhook(ctx context.Context, webhookID model.ID) (*dto.Webhook, error)
+	ListWebhooks(ctx context.Context, offset int, limit int) ([]*dto.Webhook, error)
+
+	ListDeliveries(ctx context.Context, webhookID model.ID, offset int, limit int) ([]*dto.WebhookDelivery, error)
+}
@@ -0,0 +1,24 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+const (
+	SignatureHeader = "X-Signature"
+	signaturePrefix = "sha256="
+)
+
+// Sign returns the X-Signature header value: HMAC-SHA256 of the body keyed by the secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature in constant time, receivers can use it to authenticate deliveries.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
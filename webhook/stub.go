@@ -0,0 +1,51 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/lameaux/golang-product-reviews/dto"
+	"github.com/lameaux/golang-product-reviews/model"
+)
+
+var _ Manager = (*StubManager)(nil)
+
+type StubManager struct {
+	Webhooks   []*dto.Webhook
+	Deliveries []*dto.WebhookDelivery
+}
+
+func (s *StubManager) CreateWebhook(ctx context.Context, w *dto.Webhook) (model.ID, error) {
+	return len(s.Webhooks) + 1, nil
+}
+
+func (s *StubManager) UpdateWebhook(ctx context.Context, webhookID model.ID, w *dto.Webhook) error {
+	if webhookID > len(s.Webhooks) {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *StubManager) DeleteWebhook(ctx context.Context, webhookID model.ID) error {
+	if webhookID > len(s.Webhooks) {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *StubManager) GetWebhook(ctx context.Context, webhookID model.ID) (*dto.Webhook, error) {
+	if webhookID > len(s.Webhooks) {
+		return nil, nil
+	}
+
+	return s.Webhooks[webhookID-1], nil
+}
+
+func (s *StubManager) ListWebhooks(context.Context, int, int) ([]*dto.Webhook, error) {
+	return s.Webhooks, nil
+}
+
+func (s *StubManager) ListDeliveries(ctx context.Context, webhookID model.ID, offset int, limit int) ([]*dto.WebhookDelivery, error) {
+	return s.Deliveries, nil
+}